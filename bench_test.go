@@ -1,4 +1,4 @@
-package main
+package benchmarks
 
 import (
 	"bytes"
@@ -8,11 +8,13 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	intintmap "github.com/brentp/intintmap"
 	christomic "github.com/chris-tomich/go-fast-hashmap"
 	cornelk "github.com/cornelk/hashmap"
 	lfmap "github.com/fastgeert/go-lfmap"
+	"github.com/kovetskiy/benchmarks-go/workload"
 	suncat "github.com/suncat2000/hashmap"
 	"github.com/vmihailenco/msgpack"
 )
@@ -296,6 +298,7 @@ func BenchmarkMaps_Intintmap_Size10K_WithLock_Fill10K(b *testing.B) {
 }
 
 func BenchmarkMaps_SuncatHashmap_Fill10K(b *testing.B) {
+	flagNonconformingMap(b, "Int64", "SuncatHashmap", createIntKeys(10000))
 	for i := 0; i < b.N; i++ {
 		table := suncat.NewHashMap(16)
 		var k int64
@@ -310,6 +313,7 @@ func BenchmarkMaps_ChrisTomichHashmap_Fill10K(b *testing.B) {
 	for k := 0; k < 10000; k++ {
 		keys = append(keys, strconv.Itoa(k))
 	}
+	flagNonconformingMap(b, "String_Short", "ChrisTomichHashmap", keys)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		table := christomic.New(10000)
@@ -324,6 +328,7 @@ func BenchmarkMaps_LFMap_Fill10K(b *testing.B) {
 	for k := 0; k < 10000; k++ {
 		keys = append(keys, strconv.Itoa(k))
 	}
+	flagNonconformingMap(b, "String_Short", "LFMap", keys)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		table := lfmap.NewLFmap()
@@ -837,11 +842,13 @@ func BenchmarkSerializers_DecodeMsgpackStructConcrete(b *testing.B) {
 	}
 }
 
+// BenchmarkChan_Struct_* run no job on purpose: they measure the cost of a
+// single handoff alone. BenchmarkChan_Matrix, BenchmarkQueue_Topology and
+// the BenchmarkWorker_* benchmarks put workload jobs behind the channels.
 func BenchmarkChan_Struct_Close(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		ch := make(chan struct{})
 		go func() {
-			// doCpuJob(100000)
 			close(ch)
 		}()
 		<-ch
@@ -852,25 +859,70 @@ func BenchmarkChan_Struct_Send(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		ch := make(chan struct{})
 		go func() {
-			// doCpuJob(100000)
 			ch <- struct{}{}
 		}()
 		<-ch
 	}
 }
 
-func doCpuJob(max int) {
-	i := 0
-	for i := 0; i < max; i++ {
-		i++
+var (
+	cpuJobs      = map[string]workload.Job{}
+	cpuJobsMutex = sync.Mutex{}
+)
+
+// cpuJob calibrates a job on its first use, so that only the benchmarks
+// which are actually selected pay for the calibration.
+func cpuJob(name string, kind workload.Kind, cost time.Duration) workload.Job {
+	cpuJobsMutex.Lock()
+	defer cpuJobsMutex.Unlock()
+
+	job, ok := cpuJobs[name]
+	if !ok {
+		job = workload.Calibrate(kind, cost)
+		cpuJobs[name] = job
 	}
-	_ = i
+
+	return job
+}
+
+const BenchmarkWorker_CPUJobCost = 50 * time.Microsecond
+
+func workerJob() workload.Job {
+	return cpuJob("Arithmetic_50us", workload.Arithmetic, BenchmarkWorker_CPUJobCost)
+}
+
+var workloadJobs = []struct {
+	name string
+	kind workload.Kind
+	cost time.Duration
+}{
+	{"Empty", workload.Arithmetic, 0},
+	{"Arithmetic_1us", workload.Arithmetic, time.Microsecond},
+	{"Arithmetic_10us", workload.Arithmetic, 10 * time.Microsecond},
+	{"Arithmetic_100us", workload.Arithmetic, 100 * time.Microsecond},
+	{"Hash_10us", workload.Hash, 10 * time.Microsecond},
+	{"Chase_10us", workload.Chase, 10 * time.Microsecond},
 }
 
-const BenchmarkWorker_CPUJob = 100000
+func BenchmarkWorkload(b *testing.B) {
+	for _, job := range workloadJobs {
+		b.Run(job.name, func(b *testing.B) {
+			job := cpuJob(job.name, job.kind, job.cost)
+			result := uint64(0)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				result += job.Run(uint64(i))
+			}
+			workload.Sink.Add(result)
+		})
+	}
+}
 
 func BenchmarkWorker_Single_Chan(b *testing.B) {
 	threads := 5
+	job := workerJob()
+	result := uint64(0)
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ch := make(chan struct{})
 		wg := &sync.WaitGroup{}
@@ -880,7 +932,7 @@ func BenchmarkWorker_Single_Chan(b *testing.B) {
 			for {
 				i++
 				<-ch
-				doCpuJob(BenchmarkWorker_CPUJob)
+				result += job.Run(uint64(i))
 				if i == threads {
 					wg.Done()
 					return
@@ -896,26 +948,31 @@ func BenchmarkWorker_Single_Chan(b *testing.B) {
 
 		wg.Wait()
 	}
+	workload.Sink.Add(result)
 }
 
 func BenchmarkWorker_Multiple_Lock(b *testing.B) {
 	threads := 5
+	job := workerJob()
+	result := uint64(0)
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		wg := &sync.WaitGroup{}
 
 		mutex := &sync.Mutex{}
 		for i := 0; i < threads; i++ {
 			wg.Add(1)
-			go func() {
+			go func(seed uint64) {
 				mutex.Lock()
-				doCpuJob(BenchmarkWorker_CPUJob)
+				result += job.Run(seed)
 				mutex.Unlock()
 				wg.Done()
-			}()
+			}(uint64(i))
 		}
 
 		wg.Wait()
 	}
+	workload.Sink.Add(result)
 }
 
 func inlineFactorial(n, prd int) int {
@@ -0,0 +1,104 @@
+package benchmarks
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kovetskiy/benchmarks-go/workload"
+)
+
+var chanBuffers = []int{0, 1, 64, 1024}
+
+var chanTopologies = []struct {
+	name      string
+	producers int
+	consumers int
+}{
+	{"1:1", 1, 1},
+	{"N:1", 4, 1},
+	{"1:N", 1, 4},
+	{"N:M", 4, 8},
+}
+
+type chanPayload struct {
+	data [64]byte
+}
+
+// passJobs are the Empty, Arithmetic_1us and Arithmetic_10us workloadJobs,
+// which the consumers of the channel and queue benchmarks run per value. The
+// longer ones would make passing 10000 values take seconds.
+var passJobs = workloadJobs[:3]
+
+// passChan sends items copies of value from the producers to the consumers,
+// which run the job for every value they receive, and returns the sum of the
+// job results.
+func passChan[T any](ch chan T, value T, job workload.Job, items, producers, consumers int) uint64 {
+	var sum atomic.Uint64
+	wg := &sync.WaitGroup{}
+
+	for producer := 0; producer < producers; producer++ {
+		count := items / producers
+		if producer < items%producers {
+			count++
+		}
+
+		go func() {
+			for k := 0; k < count; k++ {
+				ch <- value
+			}
+		}()
+	}
+
+	for consumer := 0; consumer < consumers; consumer++ {
+		count := items / consumers
+		if consumer < items%consumers {
+			count++
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local uint64
+			for k := 0; k < count; k++ {
+				<-ch
+				local += job.Run(uint64(k))
+			}
+			sum.Add(local)
+		}()
+	}
+
+	wg.Wait()
+	return sum.Load()
+}
+
+func benchmarkChanElement[T any](b *testing.B, name string, value T) {
+	b.Run("Element="+name, func(b *testing.B) {
+		for _, job := range passJobs {
+			for _, buffer := range chanBuffers {
+				for _, topology := range chanTopologies {
+					name := "Job=" + job.name + "/Buffer=" + strconv.Itoa(buffer) + "/Topology=" + topology.name
+					b.Run(name, func(b *testing.B) {
+						job := cpuJob(job.name, job.kind, job.cost)
+						result := uint64(0)
+						b.ResetTimer()
+						for i := 0; i < b.N; i++ {
+							ch := make(chan T, buffer)
+							result += passChan(ch, value, job, 10000, topology.producers, topology.consumers)
+						}
+						reportPerEntry(b, 10000)
+						workload.Sink.Add(result)
+					})
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkChan_Matrix(b *testing.B) {
+	benchmarkChanElement(b, "Struct0", struct{}{})
+	benchmarkChanElement(b, "Int64", int64(1))
+	benchmarkChanElement(b, "Struct64", chanPayload{})
+	benchmarkChanElement(b, "Pointer", &chanPayload{})
+}
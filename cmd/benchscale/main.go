@@ -0,0 +1,132 @@
+// Command benchscale re-runs the concurrency benchmarks at GOMAXPROCS of 1,
+// 2, 4 and so on up to the number of CPUs, the same as go test -cpu does,
+// and prints how every benchmark scales: its speedup over GOMAXPROCS=1 and
+// the efficiency, which is the speedup per processor.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const defaultBench = "Worker|Chan|Queue|Contention|Lock_|Goroutine|Maps_ShardMap_Shards"
+
+func main() {
+	var (
+		bench     = flag.String("bench", defaultBench, "regexp of the benchmarks to run")
+		benchtime = flag.String("benchtime", "1s", "passed to go test -benchtime")
+		pkg       = flag.String("pkg", ".", "package with the benchmarks")
+		maxProcs  = flag.Int("max", runtime.NumCPU(), "highest GOMAXPROCS to run at")
+	)
+	flag.Parse()
+
+	if *maxProcs < 1 {
+		fmt.Fprintf(os.Stderr, "-max is %d, expected at least 1\n", *maxProcs)
+		os.Exit(1)
+	}
+
+	procs := []int{}
+	for p := 1; p < *maxProcs; p *= 2 {
+		procs = append(procs, p)
+	}
+	procs = append(procs, *maxProcs)
+
+	results := map[string]map[int]float64{}
+	names := []string{}
+	for _, p := range procs {
+		fmt.Fprintf(os.Stderr, "running at GOMAXPROCS=%d\n", p)
+
+		output, err := run(*pkg, *bench, *benchtime, p)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		for name, nsPerOp := range parse(output, p) {
+			if results[name] == nil {
+				results[name] = map[int]float64{}
+				names = append(names, name)
+			}
+			results[name][p] = nsPerOp
+		}
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "benchmark\tprocs\tns/op\tspeedup\tefficiency\t")
+	for _, name := range names {
+		base, ok := results[name][1]
+		for _, p := range procs {
+			nsPerOp, found := results[name][p]
+			if !found {
+				continue
+			}
+
+			speedup, efficiency := "-", "-"
+			if ok {
+				speedup = fmt.Sprintf("%.2fx", base/nsPerOp)
+				efficiency = fmt.Sprintf("%.0f%%", base/nsPerOp/float64(p)*100)
+			}
+
+			fmt.Fprintf(writer, "%s\t%d\t%.1f\t%s\t%s\t\n", name, p, nsPerOp, speedup, efficiency)
+		}
+	}
+	writer.Flush()
+}
+
+func run(pkg, bench, benchtime string, procs int) ([]byte, error) {
+	cmd := exec.Command(
+		"go", "test", pkg,
+		"-run", "^$",
+		"-bench", bench,
+		"-benchtime", benchtime,
+		"-cpu", strconv.Itoa(procs),
+	)
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		os.Stderr.Write(output)
+		return nil, fmt.Errorf("go test at GOMAXPROCS=%d: %s", procs, err)
+	}
+
+	return output, nil
+}
+
+// parse reads ns/op of every benchmark from go test output, dropping the
+// -procs suffix go test appends to the names.
+func parse(output []byte, procs int) map[string]float64 {
+	results := map[string]float64{}
+	suffix := "-" + strconv.Itoa(procs)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+
+		for i := 2; i+1 < len(fields); i += 2 {
+			if fields[i+1] != "ns/op" {
+				continue
+			}
+
+			nsPerOp, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+
+			results[strings.TrimSuffix(fields[0], suffix)] = nsPerOp
+			break
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// writeConcurrent runs config.Goroutines workers which split config.Records
+// evenly and write config.Batch of them at a time. write stores the records
+// for the worker and syncs them if asked to, which happens every
+// config.SyncEvery records of the worker. The latency of a record is the
+// time its write call took, including any wait for a lock.
+func writeConcurrent(
+	config config,
+	record []byte,
+	write func(worker int, data []byte, sync bool) error,
+) (writeStats, error) {
+	buffer := alignedBuffer(config.Batch*len(record), config.Align)[:0]
+	for i := 0; i < config.Batch; i++ {
+		buffer = append(buffer, record...)
+	}
+
+	var (
+		started = time.Now()
+		stats   = make([]writeStats, config.Goroutines)
+		errs    = make([]error, config.Goroutines)
+		wg      = sync.WaitGroup{}
+	)
+	for worker := 0; worker < config.Goroutines; worker++ {
+		stats[worker] = newWriteStats(config)
+
+		share := config.Records / config.Goroutines
+		if worker < config.Records%config.Goroutines {
+			share++
+		}
+
+		wg.Add(1)
+		go func(worker, share int) {
+			defer wg.Done()
+
+			unsynced := 0
+			for stats[worker].records < share {
+				if config.Duration > 0 && time.Since(started) >= config.Duration {
+					break
+				}
+
+				batch := min(config.Batch, share-stats[worker].records)
+				unsynced += batch
+
+				due := config.SyncEvery > 0 &&
+					(unsynced >= config.SyncEvery || stats[worker].records+batch == share)
+				if due {
+					unsynced = 0
+				}
+
+				writeStarted := time.Now()
+
+				err := write(worker, buffer[:batch*len(record)], due)
+				if err != nil {
+					errs[worker] = err
+					return
+				}
+
+				stats[worker].writes++
+
+				latency := time.Since(writeStarted)
+				for i := 0; i < batch; i++ {
+					stats[worker].observe(latency)
+				}
+			}
+
+			// records written after the last sync when the time is up
+			if config.SyncEvery > 0 && unsynced > 0 {
+				errs[worker] = write(worker, nil, true)
+			}
+		}(worker, share)
+	}
+
+	wg.Wait()
+
+	total := newWriteStats(config)
+	var err error
+	for worker := range stats {
+		total.merge(stats[worker])
+		if err == nil {
+			err = errs[worker]
+		}
+	}
+
+	return total, err
+}
+
+// writeSharedMutex writes to one file from all goroutines, holding a mutex
+// over every write and sync.
+func writeSharedMutex(config config, mode syncMode, file *os.File, record []byte) (writeStats, error) {
+	mutex := sync.Mutex{}
+
+	return writeConcurrent(config, record, func(worker int, data []byte, sync bool) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		_, err := file.Write(data)
+		if err == nil && sync {
+			err = mode.sync(file)
+		}
+
+		return err
+	})
+}
+
+// writeSharedPwrite writes to one file from all goroutines without a lock,
+// every write reserves its offset first and writes there with pwrite.
+func writeSharedPwrite(config config, mode syncMode, file *os.File, record []byte) (writeStats, error) {
+	var next atomic.Int64
+
+	return writeConcurrent(config, record, func(worker int, data []byte, sync bool) error {
+		offset := next.Add(int64(len(data))) - int64(len(data))
+
+		_, err := file.WriteAt(data, offset)
+		if err == nil && sync {
+			err = mode.sync(file)
+		}
+
+		return err
+	})
+}
+
+// writePerFile gives every goroutine a file of its own.
+func writePerFile(config config, mode syncMode, files []*os.File, record []byte) (writeStats, error) {
+	return writeConcurrent(config, record, func(worker int, data []byte, sync bool) error {
+		_, err := files[worker].Write(data)
+		if err == nil && sync {
+			err = mode.sync(files[worker])
+		}
+
+		return err
+	})
+}
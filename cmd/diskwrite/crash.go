@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/kovetskiy/benchmarks-go/ledger"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crashResult compares what the killed writer acknowledged as durable with
+// what was found in the file afterwards.
+type crashResult struct {
+	Path      string `json:"path"`
+	Sync      string `json:"sync"`
+	SyncEvery int    `json:"sync_every,omitempty"`
+
+	Killed       bool  `json:"killed"`
+	Acknowledged int64 `json:"acknowledged"`
+	Recovered    int64 `json:"recovered"`
+	Torn         int   `json:"torn"`
+	Corrupt      int   `json:"corrupt"`
+	Held         bool  `json:"held"`
+}
+
+// verify checks that every acknowledged record is recovered and that the
+// file holds nothing but intact records and possibly a torn last one.
+func (result *crashResult) verify(acknowledged int64) {
+	result.Acknowledged = acknowledged
+	result.Held = result.Recovered >= acknowledged && result.Corrupt == 0
+}
+
+// encodeNumbered fills record with the CRC32-C of the rest of the record
+// followed by a packet with the given id and zero padding.
+func encodeNumbered(record []byte, id int64) {
+	clear(record)
+	ledger.AppendPacket(record[4:4], &ledger.Packet{ID: id, CreatedAt: time.Now().UnixNano()})
+	binary.BigEndian.PutUint32(record, crc32.Checksum(record[4:], crcTable))
+}
+
+// decodeNumbered returns the id of the record or false if it is not intact.
+func decodeNumbered(record []byte) (int64, bool) {
+	if crc32.Checksum(record[4:], crcTable) != binary.BigEndian.Uint32(record) {
+		return 0, false
+	}
+
+	packet := ledger.Packet{}
+	err := ledger.DecodePacket(record[4:], &packet)
+	if err != nil {
+		return 0, false
+	}
+
+	return packet.ID, true
+}
+
+func numberedRecordSize(config config) (int, error) {
+	size := config.RecordSize
+	if size == 0 {
+		size = 4 + ledger.PacketSize
+	}
+	if size < 4+ledger.PacketSize {
+		return 0, fmt.Errorf(
+			"record size %d is less than the checksummed packet size %d",
+			size, 4+ledger.PacketSize,
+		)
+	}
+	if config.Align > 1 {
+		size = (size + config.Align - 1) / config.Align * config.Align
+	}
+
+	return size, nil
+}
+
+// writeNumbered writes records numbered from 1 and after every point at
+// which they are durable by the sync mode writes the last number to acks.
+// Nothing is durable without syncing, so none mode acknowledges nothing.
+func writeNumbered(config config, acks io.Writer) error {
+	mode, err := findSyncMode(config.Sync)
+	if err != nil {
+		return err
+	}
+
+	size, err := numberedRecordSize(config)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|mode.flags, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var (
+		record   = make([]byte, size)
+		ack      = make([]byte, 8)
+		unsynced = 0
+	)
+	for id := int64(1); id <= int64(config.Records); id++ {
+		encodeNumbered(record, id)
+
+		_, err := file.Write(record)
+		if err != nil {
+			return err
+		}
+
+		unsynced++
+		if mode.sync != nil {
+			if unsynced < max(config.SyncEvery, 1) && id < int64(config.Records) {
+				continue
+			}
+
+			err := mode.sync(file)
+			if err != nil {
+				return err
+			}
+		}
+
+		unsynced = 0
+		if mode.name == syncNone {
+			continue
+		}
+
+		binary.BigEndian.PutUint64(ack, uint64(id))
+		_, err = acks.Write(ack)
+		if err != nil {
+			return err
+		}
+	}
+
+	return file.Close()
+}
+
+// scanNumbered reads the records back. Recovered is the count of intact
+// records in sequence from the start of the file. Any other written record
+// which is not intact or not in its place is corrupt, or torn if it is the
+// last one in the file. Zeroed records are only unwritten ones past the last
+// written record, a zeroed record before it is a lost one and is corrupt.
+func scanNumbered(path string, size int) (crashResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return crashResult{}, err
+	}
+
+	written := len(data)
+	for written > 0 && data[written-1] == 0 {
+		written--
+	}
+	written = min((written+size-1)/size*size, len(data))
+
+	result := crashResult{}
+	intact := true
+	for offset := 0; offset < written; offset += size {
+		record := data[offset:min(offset+size, written)]
+		last := offset+size >= written
+
+		if len(record) == size {
+			id, ok := decodeNumbered(record)
+			if ok && intact && id == result.Recovered+1 {
+				result.Recovered++
+				continue
+			}
+			if ok && id == int64(offset/size)+1 {
+				continue
+			}
+		}
+
+		intact = false
+		if last {
+			result.Torn++
+		} else {
+			result.Corrupt++
+		}
+	}
+
+	return result, nil
+}
+
+// runCrash starts this command again as a child writing numbered records,
+// kills it with SIGKILL after the given time and checks that every record
+// the child acknowledged as durable is intact in the file.
+//
+// Killing the process does not lose the page cache, so this checks what a
+// process crash keeps; losing the acknowledged records of a power loss
+// needs the machine or the device to be cut off instead.
+func runCrash(config config, after time.Duration) (crashResult, error) {
+	if config.Direct || config.Layout != "append" || config.Writer != writerWrite {
+		return crashResult{}, fmt.Errorf(
+			"%w: -crash writes with the %s writer and the append layout without O_DIRECT",
+			errUnsupported, writerWrite,
+		)
+	}
+
+	mode, err := findSyncMode(config.Sync)
+	if err != nil {
+		return crashResult{}, err
+	}
+
+	if mode.sync == nil {
+		config.SyncEvery = 0
+	}
+
+	size, err := numberedRecordSize(config)
+	if err != nil {
+		return crashResult{}, err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return crashResult{}, err
+	}
+
+	// unlike StdoutPipe, the pipe is not closed by Wait, so the acks the
+	// child sent right before it was killed can still be read
+	acks, writer, err := os.Pipe()
+	if err != nil {
+		return crashResult{}, err
+	}
+	defer acks.Close()
+
+	child := exec.Command(executable, append(os.Args[1:], "-crash-child")...)
+	child.Stdout = writer
+	child.Stderr = os.Stderr
+
+	err = child.Start()
+	writer.Close()
+	if err != nil {
+		return crashResult{}, err
+	}
+
+	acknowledged := make(chan int64)
+	go func() {
+		last := int64(0)
+		ack := make([]byte, 8)
+		for {
+			_, err := io.ReadFull(acks, ack)
+			if err != nil {
+				acknowledged <- last
+				return
+			}
+
+			last = int64(binary.BigEndian.Uint64(ack))
+		}
+	}()
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- child.Wait()
+	}()
+
+	killed := false
+	select {
+	case err := <-exited:
+		if err != nil {
+			return crashResult{}, fmt.Errorf("writer exited before it was killed: %s", err)
+		}
+	case <-time.After(after):
+		err := child.Process.Kill()
+		if err != nil {
+			return crashResult{}, err
+		}
+
+		<-exited
+		killed = true
+	}
+
+	result, err := scanNumbered(config.Path, size)
+	if err != nil {
+		return crashResult{}, err
+	}
+
+	result.Path = config.Path
+	result.Sync = config.Sync
+	result.SyncEvery = config.SyncEvery
+	result.Killed = killed
+	result.verify(<-acknowledged)
+
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanNumbered(t *testing.T) {
+	const records = 10
+
+	tests := []struct {
+		name         string
+		damage       func(data []byte, size int) []byte
+		acknowledged int64
+		expected     crashResult
+	}{
+		{
+			name:         "Intact",
+			damage:       func(data []byte, size int) []byte { return data },
+			acknowledged: records,
+			expected:     crashResult{Recovered: 10, Held: true},
+		},
+		{
+			name: "TornUnacknowledged",
+			damage: func(data []byte, size int) []byte {
+				return data[:len(data)-size/2]
+			},
+			acknowledged: records - 1,
+			expected:     crashResult{Recovered: 9, Torn: 1, Held: true},
+		},
+		{
+			name: "TornAcknowledged",
+			damage: func(data []byte, size int) []byte {
+				return data[:len(data)-size/2]
+			},
+			acknowledged: records,
+			expected:     crashResult{Recovered: 9, Torn: 1, Held: false},
+		},
+		{
+			name: "FlippedByte",
+			damage: func(data []byte, size int) []byte {
+				data[3*size+size/2] ^= 1
+				return data
+			},
+			acknowledged: records,
+			expected:     crashResult{Recovered: 3, Corrupt: 1, Held: false},
+		},
+		{
+			name: "ZeroedHole",
+			damage: func(data []byte, size int) []byte {
+				clear(data[4*size : 5*size])
+				return data
+			},
+			acknowledged: records,
+			expected:     crashResult{Recovered: 4, Corrupt: 1, Held: false},
+		},
+		{
+			name: "TornBeforeZeros",
+			damage: func(data []byte, size int) []byte {
+				clear(data[len(data)-size/2:])
+				return append(data, make([]byte, 3*size)...)
+			},
+			acknowledged: records - 1,
+			expected:     crashResult{Recovered: 9, Torn: 1, Held: true},
+		},
+		{
+			name: "TrailingZeros",
+			damage: func(data []byte, size int) []byte {
+				return append(data, make([]byte, 3*size)...)
+			},
+			acknowledged: records,
+			expected:     crashResult{Recovered: 10, Held: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := config{
+				Path:    filepath.Join(t.TempDir(), "records"),
+				Records: records,
+				Sync:    syncNone,
+			}
+
+			err := writeNumbered(config, io.Discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			size, err := numberedRecordSize(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(config.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = os.WriteFile(config.Path, test.damage(data, size), 0666)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := scanNumbered(config.Path, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result.verify(test.acknowledged)
+			test.expected.Acknowledged = test.acknowledged
+			if result != test.expected {
+				t.Errorf("scanned %+v, expected %+v", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestWriteNumbered_Acks(t *testing.T) {
+	for _, test := range []struct {
+		sync string
+		acks int
+	}{
+		{sync: "fsync", acks: 10},
+		{sync: syncNone, acks: 0},
+	} {
+		t.Run(test.sync, func(t *testing.T) {
+			config := config{
+				Path:      filepath.Join(t.TempDir(), "records"),
+				Records:   10,
+				Sync:      test.sync,
+				SyncEvery: 1,
+			}
+
+			acks := bytes.Buffer{}
+			err := writeNumbered(config, &acks)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if acks.Len() != test.acks*8 {
+				t.Errorf("%d bytes of acks, expected %d acks", acks.Len(), test.acks)
+			}
+		})
+	}
+}
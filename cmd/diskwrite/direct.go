@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// directAlign is the alignment of O_DIRECT buffers, offsets and lengths
+// unless -align asks for another one. It is the page size and a multiple of
+// the logical block size of practically every device.
+const directAlign = 4096
+
+// alignedBuffer returns a slice of size bytes which starts at an address
+// that is a multiple of align.
+func alignedBuffer(size, align int) []byte {
+	if align <= 1 {
+		return make([]byte, size)
+	}
+
+	buffer := make([]byte, size+align)
+	offset := 0
+	if remainder := int(uintptr(unsafe.Pointer(&buffer[0])) & uintptr(align-1)); remainder != 0 {
+		offset = align - remainder
+	}
+
+	return buffer[offset : offset+size : offset+size]
+}
+
+// openDirect opens the file with O_DIRECT and falls back to writing through
+// the page cache if the platform or the file system does not support it,
+// tmpfs for instance rejects it with EINVAL. It reports whether the file is
+// opened with O_DIRECT.
+func openDirect(layout layout, path string, size int64, flags int) (*os.File, bool, error) {
+	if oDirect == 0 {
+		fmt.Fprintln(os.Stderr, "O_DIRECT is not supported on this platform, writing through the page cache")
+		file, err := openLayout(layout, path, size, flags)
+		return file, false, err
+	}
+
+	file, err := openLayout(layout, path, size, flags|oDirect)
+	if errors.Is(err, syscall.EINVAL) {
+		fmt.Fprintf(os.Stderr, "the file system of %s rejects O_DIRECT, writing through the page cache\n", path)
+		file, err = openLayout(layout, path, size, flags)
+		return file, false, err
+	}
+
+	return file, err == nil, err
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type commitRequest struct {
+	record []byte
+	done   chan error
+}
+
+// writeGroupCommit runs config.Producers goroutines which submit one record
+// at a time and wait until it is durable. A single committer takes whatever
+// is queued, up to config.Batch records, writes it with one call and syncs
+// it once for the whole group. The latency is the time a producer waited
+// for its record to be committed.
+func writeGroupCommit(config config, mode syncMode, file *os.File, record []byte) (writeStats, error) {
+	requests := make(chan commitRequest, config.Producers)
+
+	var (
+		commits   = 0
+		committed = make(chan error, 1)
+	)
+	go func() {
+		var (
+			batch  = make([]commitRequest, 0, config.Batch)
+			buffer = alignedBuffer(config.Batch*len(record), config.Align)[:0]
+			failed error
+		)
+
+		for request := range requests {
+			batch = append(batch[:0], request)
+			buffer = append(buffer[:0], request.record...)
+
+		drain:
+			for len(batch) < config.Batch {
+				select {
+				case request, ok := <-requests:
+					if !ok {
+						break drain
+					}
+
+					batch = append(batch, request)
+					buffer = append(buffer, request.record...)
+				default:
+					break drain
+				}
+			}
+
+			err := failed
+			if err == nil {
+				_, err = file.Write(buffer)
+			}
+			if err == nil && mode.sync != nil {
+				err = mode.sync(file)
+			}
+
+			failed = err
+			commits++
+
+			for _, request := range batch {
+				request.done <- err
+			}
+		}
+
+		committed <- failed
+	}()
+
+	var (
+		claimed atomic.Int64
+		started = time.Now()
+		stats   = make([]writeStats, config.Producers)
+		errs    = make([]error, config.Producers)
+		wg      = sync.WaitGroup{}
+	)
+	for producer := 0; producer < config.Producers; producer++ {
+		stats[producer] = newWriteStats(config)
+
+		wg.Add(1)
+		go func(producer int) {
+			defer wg.Done()
+
+			done := make(chan error, 1)
+			for claimed.Add(1) <= int64(config.Records) {
+				if config.Duration > 0 && time.Since(started) >= config.Duration {
+					return
+				}
+
+				submitted := time.Now()
+				requests <- commitRequest{record: record, done: done}
+
+				err := <-done
+				if err != nil {
+					errs[producer] = err
+					return
+				}
+
+				stats[producer].observe(time.Since(submitted))
+			}
+		}(producer)
+	}
+
+	wg.Wait()
+	close(requests)
+
+	err := <-committed
+
+	total := newWriteStats(config)
+	total.writes = commits
+	for producer := range stats {
+		total.merge(stats[producer])
+		if err == nil {
+			err = errs[producer]
+		}
+	}
+
+	return total, err
+}
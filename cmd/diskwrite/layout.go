@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// layout prepares the file records are written to. Except for append, the
+// file already has its full size when writing starts, so writes overwrite
+// allocated blocks instead of extending the file.
+type layout struct {
+	name    string
+	prepare func(path string, size int64) error
+}
+
+var layouts = []layout{
+	{name: "append"},
+	{name: "fallocate", prepare: prepareFallocate},
+	{name: "zero", prepare: prepareZero},
+	{name: "reuse", prepare: prepareReuse},
+}
+
+func layoutNames() string {
+	names := []string{}
+	for _, layout := range layouts {
+		names = append(names, layout.name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func findLayout(name string) (layout, error) {
+	for _, layout := range layouts {
+		if layout.name == name {
+			return layout, nil
+		}
+	}
+
+	return layout{}, fmt.Errorf("unknown layout %q, expected one of %s", name, layoutNames())
+}
+
+// openLayout prepares the file for size bytes of records and opens it with
+// flags, which include the access mode, for writing from the start.
+func openLayout(layout layout, path string, size int64, flags int) (*os.File, error) {
+	flags |= os.O_CREATE
+	if layout.prepare == nil {
+		return os.OpenFile(path, flags|os.O_TRUNC, 0666)
+	}
+
+	err := layout.prepare(path, size)
+	if err != nil {
+		return nil, fmt.Errorf("%s layout: %w", layout.name, err)
+	}
+
+	return os.OpenFile(path, flags, 0666)
+}
+
+// prepareFallocate creates the file with its blocks allocated but unwritten.
+func prepareFallocate(path string, size int64) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = fallocate(file, size)
+	if err != nil {
+		return err
+	}
+
+	err = file.Sync()
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// prepareZero creates the file and writes zeros over its whole size.
+func prepareZero(path string, size int64) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	zeros := make([]byte, 1<<20)
+	for written := int64(0); written < size; {
+		chunk := min(int64(len(zeros)), size-written)
+
+		_, err := file.Write(zeros[:chunk])
+		if err != nil {
+			return err
+		}
+
+		written += chunk
+	}
+
+	err = file.Sync()
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// prepareReuse keeps the file of a previous run if it is large enough and
+// zeroes a new one otherwise.
+func prepareReuse(path string, size int64) error {
+	info, err := os.Stat(path)
+	if err == nil && info.Size() >= size {
+		return nil
+	}
+
+	return prepareZero(path, size)
+}
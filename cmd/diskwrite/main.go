@@ -0,0 +1,279 @@
+// Command diskwrite measures how fast encoded ledger packets can be written
+// to a file under a given durability mode.
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type config struct {
+	Path       string
+	Records    int
+	RecordSize int
+	Align      int
+	Sync       string
+	SyncEvery  int
+	Layout     string
+	Writer     string
+	Direct     bool
+	Batch      int
+	Producers  int
+	Goroutines int
+	Duration   time.Duration
+	Samples    bool
+}
+
+type result struct {
+	Path       string `json:"path"`
+	Sync       string `json:"sync"`
+	Layout     string `json:"layout"`
+	Writer     string `json:"writer"`
+	Direct     bool   `json:"direct"`
+	SyncEvery  int    `json:"sync_every,omitempty"`
+	Batch      int    `json:"batch"`
+	Producers  int    `json:"producers,omitempty"`
+	Goroutines int    `json:"goroutines,omitempty"`
+	Records    int    `json:"records"`
+	RecordSize int    `json:"record_size"`
+	Bytes      int64  `json:"bytes"`
+	Writes     int    `json:"writes"`
+
+	Duration      time.Duration `json:"duration_ns"`
+	RecordsPerSec float64       `json:"records_per_sec"`
+	BytesPerSec   float64       `json:"bytes_per_sec"`
+
+	// Latencies are per record: the write and sync it took part in, or the
+	// wait for the commit with group commit.
+	LatencyMean time.Duration `json:"latency_mean_ns"`
+	LatencyP50  time.Duration `json:"latency_p50_ns"`
+	LatencyP90  time.Duration `json:"latency_p90_ns"`
+	LatencyP99  time.Duration `json:"latency_p99_ns"`
+	LatencyP999 time.Duration `json:"latency_p999_ns"`
+	LatencyMax  time.Duration `json:"latency_max_ns"`
+
+	Samples []time.Duration `json:"-"`
+}
+
+// stringList is a flag holding comma-separated strings.
+type stringList []string
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = strings.Split(value, ",")
+	return nil
+}
+
+// intList is a flag holding comma-separated integers.
+type intList []int
+
+func (list *intList) String() string {
+	values := []string{}
+	for _, value := range *list {
+		values = append(values, strconv.Itoa(value))
+	}
+
+	return strings.Join(values, ",")
+}
+
+func (list *intList) Set(value string) error {
+	*list = nil
+	for _, field := range strings.Split(value, ",") {
+		number, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return err
+		}
+
+		*list = append(*list, number)
+	}
+
+	return nil
+}
+
+func main() {
+	config := config{}
+	flag.StringVar(&config.Path, "output", "diskwrite.test", "file to write records to")
+	flag.IntVar(&config.Records, "records", 10000, "number of records to write")
+	flag.IntVar(&config.RecordSize, "record-size", 0, "bytes per record, packets are zero-padded (0 is the packet size)")
+	flag.IntVar(&config.Align, "align", 0, "round the record size up to a multiple of this")
+	flag.BoolVar(&config.Direct, "direct", false, "open the file with O_DIRECT, aligning records to -align or 4096 bytes")
+	flag.StringVar(&config.Sync, "sync", "osync", "durability mode: "+syncModeNames())
+	flag.IntVar(&config.SyncEvery, "sync-every", 1, "records between fsync or fdatasync calls")
+	flag.DurationVar(&config.Duration, "duration", 0, "stop after this long even if not all records are written (0 is no limit)")
+	layouts := stringList{"append"}
+	flag.Var(&layouts, "layout", "comma-separated file layouts: "+layoutNames())
+	writerNames := stringList{writerWrite}
+	flag.Var(&writerNames, "writer", "comma-separated writers: "+strings.Join(writers, ", "))
+	batches := intList{1}
+	flag.Var(&batches, "batch", "comma-separated records per write or, with producers, per group commit")
+	producers := intList{0}
+	flag.Var(&producers, "producers", "comma-separated goroutines submitting to a group committer (0 writes sequentially)")
+	goroutines := intList{1}
+	flag.Var(&goroutines, "goroutines", "comma-separated goroutines writing at once with the shared-mutex, shared-pwrite and per-file writers")
+	matrix := flag.Bool("matrix", false, "run every durability mode")
+	format := flag.String("format", "text", "output format: text or json")
+	samples := flag.String("samples", "", "write every record's latency to this CSV file")
+	crash := flag.Bool("crash", false, "write numbered records in a child process, kill it and verify the file")
+	crashAfter := flag.Duration("crash-after", time.Second, "how long the child writes before it is killed")
+	crashChild := flag.Bool("crash-child", false, "used internally by -crash")
+	flag.Parse()
+
+	config.Samples = *samples != ""
+
+	if *crashChild {
+		err := writeNumbered(config, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if *crash {
+		config.Layout = layouts.String()
+		config.Writer = writerNames.String()
+
+		result, err := runCrash(config, *crashAfter)
+		if err == nil {
+			err = printCrash(result, *format)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	modes := []string{config.Sync}
+	if *matrix {
+		modes = nil
+		for _, mode := range syncModes {
+			modes = append(modes, mode.name)
+		}
+	}
+
+	results, err := runMatrix(config, writerNames, modes, layouts, batches, producers, goroutines)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *samples != "" {
+		err := writeSamples(*samples, results)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if len(results) == 1 {
+			err = encoder.Encode(results[0])
+		} else {
+			err = encoder.Encode(results)
+		}
+	default:
+		err = printText(results)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func printText(results []result) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(
+		writer,
+		"writer\tsync\tlayout\tdirect\tevery\tbatch\tproducers\tgoroutines\trecords\tsize\twrites\t"+
+			"duration\trecords/s\tMB/s\tmean\tp50\tp90\tp99\tp99.9\tmax\t",
+	)
+	for _, result := range results {
+		fmt.Fprintf(
+			writer, "%s\t%s\t%s\t%t\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%.2f\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			result.Writer, result.Sync, result.Layout, result.Direct,
+			result.SyncEvery, result.Batch, result.Producers, result.Goroutines,
+			result.Records, result.RecordSize, result.Writes,
+			result.Duration.Round(time.Microsecond), result.RecordsPerSec, result.BytesPerSec/1e6,
+			result.LatencyMean, result.LatencyP50, result.LatencyP90,
+			result.LatencyP99, result.LatencyP999, result.LatencyMax,
+		)
+	}
+
+	return writer.Flush()
+}
+
+func printCrash(result crashResult, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	held := "held"
+	switch {
+	case !result.Held:
+		held = "VIOLATED"
+	case result.Sync == syncNone:
+		held = "none"
+	}
+
+	_, err := fmt.Printf(
+		"%s sync=%s every=%d killed=%t: acknowledged %d, recovered %d, torn %d, corrupt %d, guarantee %s\n",
+		result.Path, result.Sync, result.SyncEvery, result.Killed,
+		result.Acknowledged, result.Recovered, result.Torn, result.Corrupt, held,
+	)
+	return err
+}
+
+// writeSamples dumps the latency of every record of every run, in the order
+// each writer recorded them, for plotting.
+func writeSamples(path string, results []result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(bufio.NewWriter(file))
+	writer.Write([]string{
+		"writer", "sync", "sync_every", "batch", "producers", "goroutines", "record", "latency_ns",
+	})
+	for _, result := range results {
+		for record, latency := range result.Samples {
+			writer.Write([]string{
+				result.Writer,
+				result.Sync,
+				strconv.Itoa(result.SyncEvery),
+				strconv.Itoa(result.Batch),
+				strconv.Itoa(result.Producers),
+				strconv.Itoa(result.Goroutines),
+				strconv.Itoa(record),
+				strconv.FormatInt(int64(latency), 10),
+			})
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+func msync(mapping []byte) error {
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_MSYNC,
+		uintptr(unsafe.Pointer(&mapping[0])),
+		uintptr(len(mapping)),
+		syscall.MS_SYNC,
+	)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// writeMapped maps the whole file and copies config.Batch records at a time
+// into the mapping. Every config.SyncEvery records the pages written since
+// the previous msync are synced.
+func writeMapped(config config, file *os.File, record []byte) (writeStats, error) {
+	size := config.Records * len(record)
+
+	// the append layout leaves the file empty and a mapping can't extend it
+	err := file.Truncate(int64(size))
+	if err != nil {
+		return writeStats{}, err
+	}
+
+	mapping, err := syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return writeStats{}, err
+	}
+
+	buffer := make([]byte, 0, config.Batch*len(record))
+	for i := 0; i < config.Batch; i++ {
+		buffer = append(buffer, record...)
+	}
+
+	var (
+		stats    = newWriteStats(config)
+		page     = os.Getpagesize()
+		offset   = 0
+		synced   = 0
+		unsynced = 0
+	)
+
+	started := time.Now()
+	for stats.records < config.Records {
+		if config.Duration > 0 && time.Since(started) >= config.Duration {
+			break
+		}
+
+		batch := min(config.Batch, config.Records-stats.records)
+		writeStarted := time.Now()
+
+		offset += copy(mapping[offset:], buffer[:batch*len(record)])
+		stats.writes++
+		unsynced += batch
+
+		if config.SyncEvery > 0 && unsynced >= config.SyncEvery {
+			err := msync(mapping[synced:offset])
+			if err != nil {
+				syscall.Munmap(mapping)
+				return stats, err
+			}
+
+			synced = offset / page * page
+			unsynced = 0
+		}
+
+		latency := time.Since(writeStarted)
+		for i := 0; i < batch; i++ {
+			stats.observe(latency)
+		}
+	}
+
+	if config.SyncEvery > 0 && unsynced > 0 {
+		err := msync(mapping[synced:offset])
+		if err != nil {
+			syscall.Munmap(mapping)
+			return stats, err
+		}
+	}
+
+	return stats, syscall.Munmap(mapping)
+}
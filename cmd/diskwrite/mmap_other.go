@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+func writeMapped(config config, file *os.File, record []byte) (writeStats, error) {
+	return writeStats{}, errors.New("the mmap writer is only supported on linux")
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+const oDSync = syscall.O_DSYNC
+
+func fdatasync(file *os.File) error {
+	return syscall.Fdatasync(int(file.Fd()))
+}
+
+func fallocate(file *os.File, size int64) error {
+	return syscall.Fallocate(int(file.Fd()), 0, 0, size)
+}
+
+const oDirect = syscall.O_DIRECT
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// Other platforms have neither O_DSYNC nor fdatasync everywhere, so odsync
+// and fdatasync fall back to their full metadata counterparts.
+const oDSync = os.O_SYNC
+
+// oDirect is zero to make -direct fall back to the page cache.
+const oDirect = 0
+
+func fdatasync(file *os.File) error {
+	return file.Sync()
+}
+
+func fallocate(file *os.File, size int64) error {
+	return errors.New("fallocate is only supported on linux")
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kovetskiy/benchmarks-go/histogram"
+	"github.com/kovetskiy/benchmarks-go/ledger"
+)
+
+const (
+	writerWrite        = "write"
+	writerMmap         = "mmap"
+	writerSharedMutex  = "shared-mutex"
+	writerSharedPwrite = "shared-pwrite"
+	writerPerFile      = "per-file"
+)
+
+var writers = []string{writerWrite, writerMmap, writerSharedMutex, writerSharedPwrite, writerPerFile}
+
+// syncMode is a way to make written records durable: either by opening the
+// file with a synchronous flag or by syncing it every config.SyncEvery
+// records.
+type syncMode struct {
+	name  string
+	flags int
+	sync  func(*os.File) error
+}
+
+var syncModes = []syncMode{
+	{name: "osync", flags: os.O_SYNC},
+	{name: "odsync", flags: oDSync},
+	{name: "fsync", sync: (*os.File).Sync},
+	{name: "fdatasync", sync: fdatasync},
+	{name: syncNone},
+}
+
+const syncNone = "none"
+
+// mappedSyncName returns how the mmap writer syncs in the mode. The only way
+// to make stores to a mapping durable is msync, so every mode but none means
+// msync every config.SyncEvery records.
+func mappedSyncName(mode syncMode) string {
+	if mode.sync == nil && mode.flags == 0 {
+		return syncNone
+	}
+
+	return "msync"
+}
+
+func syncModeNames() string {
+	names := []string{}
+	for _, mode := range syncModes {
+		names = append(names, mode.name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func findSyncMode(name string) (syncMode, error) {
+	for _, mode := range syncModes {
+		if mode.name == name {
+			return mode, nil
+		}
+	}
+
+	return syncMode{}, fmt.Errorf("unknown sync mode %q, expected one of %s", name, syncModeNames())
+}
+
+// encodeRecord encodes a packet and zero-pads it to size bytes, rounded up
+// to a multiple of align.
+func encodeRecord(packet *ledger.Packet, size, align int) ([]byte, error) {
+	if size == 0 {
+		size = ledger.PacketSize
+	}
+	if size < ledger.PacketSize {
+		return nil, fmt.Errorf("record size %d is less than the packet size %d", size, ledger.PacketSize)
+	}
+	if align > 1 {
+		size = (size + align - 1) / align * align
+	}
+
+	record := ledger.AppendPacket(alignedBuffer(size, align)[:0], packet)
+	return record[:size], nil
+}
+
+// writeStats accumulates what the writers measured. Raw samples are only
+// kept if they are going to be dumped.
+type writeStats struct {
+	records   int
+	writes    int
+	latencies *histogram.Histogram
+	samples   []time.Duration
+	keep      bool
+}
+
+func newWriteStats(config config) writeStats {
+	return writeStats{
+		latencies: histogram.New(),
+		keep:      config.Samples,
+	}
+}
+
+func (stats *writeStats) observe(latency time.Duration) {
+	stats.records++
+	stats.latencies.Record(int64(latency))
+	if stats.keep {
+		stats.samples = append(stats.samples, latency)
+	}
+}
+
+func (stats *writeStats) merge(other writeStats) {
+	stats.records += other.records
+	stats.writes += other.writes
+	stats.latencies.Merge(other.latencies)
+	stats.samples = append(stats.samples, other.samples...)
+}
+
+// errUnsupported is returned for combinations of options which can't be
+// run together.
+var errUnsupported = errors.New("unsupported combination")
+
+// normalize checks the combination of options and resets those which have
+// no effect on it, so that equal runs have equal configs. The returned mode
+// is renamed to how the writer actually syncs.
+func normalize(config config) (config, syncMode, error) {
+	mode, err := findSyncMode(config.Sync)
+	if err != nil {
+		return config, mode, err
+	}
+
+	// an empty run has no records to measure, and the mmap writer can't
+	// map an empty file
+	if config.Records < 1 {
+		return config, mode, fmt.Errorf("%d records, expected at least 1", config.Records)
+	}
+
+	config.Batch = max(config.Batch, 1)
+	config.SyncEvery = max(config.SyncEvery, 1)
+	if config.Direct && config.Align == 0 {
+		config.Align = directAlign
+	}
+
+	switch config.Writer {
+	case writerWrite:
+		config.Goroutines = 0
+		if mode.sync == nil || config.Producers > 0 {
+			config.SyncEvery = 0
+		}
+	case writerMmap:
+		if config.Producers > 0 || config.Direct {
+			return config, mode, fmt.Errorf("%w: the mmap writer supports neither producers nor O_DIRECT", errUnsupported)
+		}
+
+		config.Goroutines = 0
+		mode.name = mappedSyncName(mode)
+		if mode.name == syncNone {
+			config.SyncEvery = 0
+		}
+	case writerSharedMutex, writerSharedPwrite, writerPerFile:
+		if config.Producers > 0 {
+			return config, mode, fmt.Errorf("%w: the %s writer does not support producers", errUnsupported, config.Writer)
+		}
+
+		config.Goroutines = max(config.Goroutines, 1)
+		if mode.sync == nil {
+			config.SyncEvery = 0
+		}
+	default:
+		return config, mode, fmt.Errorf("unknown writer %q, expected one of %s", config.Writer, strings.Join(writers, ", "))
+	}
+
+	return config, mode, nil
+}
+
+func run(config config) (result, error) {
+	config, mode, err := normalize(config)
+	if err != nil {
+		return result{}, err
+	}
+
+	layout, err := findLayout(config.Layout)
+	if err != nil {
+		return result{}, err
+	}
+
+	record, err := encodeRecord(&ledger.Packet{}, config.RecordSize, config.Align)
+	if err != nil {
+		return result{}, err
+	}
+
+	paths := []string{config.Path}
+	if config.Writer == writerPerFile {
+		paths = paths[:0]
+		for goroutine := 0; goroutine < config.Goroutines; goroutine++ {
+			paths = append(paths, fmt.Sprintf("%s.%d", config.Path, goroutine))
+		}
+	}
+
+	var (
+		files = make([]*os.File, len(paths))
+		size  = int64((config.Records+len(paths)-1)/len(paths)) * int64(len(record))
+	)
+	for index, path := range paths {
+		switch {
+		case config.Writer == writerMmap:
+			files[index], err = openLayout(layout, path, size, os.O_RDWR)
+		case config.Direct:
+			files[index], config.Direct, err = openDirect(layout, path, size, os.O_WRONLY|mode.flags)
+		default:
+			files[index], err = openLayout(layout, path, size, os.O_WRONLY|mode.flags)
+		}
+		if err != nil {
+			return result{}, err
+		}
+		defer files[index].Close()
+	}
+
+	file := files[0]
+	started := time.Now()
+
+	var stats writeStats
+	switch {
+	case config.Writer == writerMmap:
+		stats, err = writeMapped(config, file, record)
+	case config.Writer == writerSharedMutex:
+		stats, err = writeSharedMutex(config, mode, file, record)
+	case config.Writer == writerSharedPwrite:
+		stats, err = writeSharedPwrite(config, mode, file, record)
+	case config.Writer == writerPerFile:
+		stats, err = writePerFile(config, mode, files, record)
+	case config.Producers > 0:
+		stats, err = writeGroupCommit(config, mode, file, record)
+	default:
+		stats, err = writeSequential(config, mode, file, record)
+	}
+	if config.Direct && errors.Is(err, syscall.EINVAL) {
+		return result{}, fmt.Errorf("%w: the file system rejects O_DIRECT writes of %d bytes, try another -align", err, len(record))
+	}
+	if err != nil {
+		return result{}, err
+	}
+
+	duration := time.Since(started)
+
+	result := result{
+		Path:          config.Path,
+		Sync:          mode.name,
+		Layout:        layout.name,
+		Writer:        config.Writer,
+		Direct:        config.Direct,
+		SyncEvery:     config.SyncEvery,
+		Batch:         config.Batch,
+		Producers:     config.Producers,
+		Goroutines:    config.Goroutines,
+		Records:       stats.records,
+		RecordSize:    len(record),
+		Bytes:         int64(stats.records) * int64(len(record)),
+		Writes:        stats.writes,
+		Duration:      duration,
+		RecordsPerSec: float64(stats.records) / duration.Seconds(),
+		BytesPerSec:   float64(stats.records*len(record)) / duration.Seconds(),
+		LatencyMean:   time.Duration(stats.latencies.Mean()),
+		LatencyP50:    time.Duration(stats.latencies.Percentile(50)),
+		LatencyP90:    time.Duration(stats.latencies.Percentile(90)),
+		LatencyP99:    time.Duration(stats.latencies.Percentile(99)),
+		LatencyP999:   time.Duration(stats.latencies.Percentile(99.9)),
+		LatencyMax:    time.Duration(stats.latencies.Max()),
+		Samples:       stats.samples,
+	}
+
+	for _, file := range files {
+		err := file.Close()
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// writeSequential writes config.Batch records per write call from a single
+// goroutine. Every record of a batch is accounted the latency of its write.
+func writeSequential(config config, mode syncMode, file *os.File, record []byte) (writeStats, error) {
+	buffer := alignedBuffer(config.Batch*len(record), config.Align)[:0]
+	for i := 0; i < config.Batch; i++ {
+		buffer = append(buffer, record...)
+	}
+
+	var (
+		stats    = newWriteStats(config)
+		unsynced = 0
+	)
+
+	started := time.Now()
+	for stats.records < config.Records {
+		if config.Duration > 0 && time.Since(started) >= config.Duration {
+			break
+		}
+
+		batch := min(config.Batch, config.Records-stats.records)
+		writeStarted := time.Now()
+
+		_, err := file.Write(buffer[:batch*len(record)])
+		if err != nil {
+			return stats, err
+		}
+
+		stats.writes++
+		unsynced += batch
+
+		if mode.sync != nil && unsynced >= config.SyncEvery {
+			err := mode.sync(file)
+			if err != nil {
+				return stats, err
+			}
+
+			unsynced = 0
+		}
+
+		latency := time.Since(writeStarted)
+		for i := 0; i < batch; i++ {
+			stats.observe(latency)
+		}
+	}
+
+	// records written after the last sync are still in the page cache
+	if mode.sync != nil && unsynced > 0 {
+		return stats, mode.sync(file)
+	}
+
+	return stats, nil
+}
+
+// expand returns every config with every one of values set.
+func expand[T any](configs []config, values []T, set func(*config, T)) []config {
+	expanded := []config{}
+	for _, config := range configs {
+		for _, value := range values {
+			set(&config, value)
+			expanded = append(expanded, config)
+		}
+	}
+
+	return expanded
+}
+
+// runMatrix runs every combination of writer, sync mode, layout, batch size,
+// producer and goroutine count one after another on the same path.
+// Combinations which differ only in options without effect on them run once,
+// unsupported ones are skipped.
+func runMatrix(
+	base config,
+	writers []string,
+	modes []string,
+	layouts []string,
+	batches []int,
+	producers []int,
+	goroutines []int,
+) ([]result, error) {
+	configs := []config{base}
+	configs = expand(configs, writers, func(config *config, writer string) { config.Writer = writer })
+	configs = expand(configs, modes, func(config *config, mode string) { config.Sync = mode })
+	configs = expand(configs, layouts, func(config *config, layout string) { config.Layout = layout })
+	configs = expand(configs, batches, func(config *config, batch int) { config.Batch = batch })
+	configs = expand(configs, producers, func(config *config, producers int) { config.Producers = producers })
+	configs = expand(configs, goroutines, func(config *config, goroutines int) { config.Goroutines = goroutines })
+
+	var (
+		results = []result{}
+		seen    = map[config]bool{}
+	)
+	for _, config := range configs {
+		normalized, mode, err := normalize(config)
+		if errors.Is(err, errUnsupported) && len(configs) > 1 {
+			continue
+		}
+		if err == nil {
+			normalized.Sync = mode.name
+			if seen[normalized] {
+				continue
+			}
+			seen[normalized] = true
+		}
+
+		result, err := run(config)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%s %s %s batch=%d producers=%d goroutines=%d: %s",
+				config.Writer, config.Sync, config.Layout,
+				config.Batch, config.Producers, config.Goroutines, err,
+			)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,163 @@
+package benchmarks
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+var goroutineStacks = []int{0, 4, 16, 64}
+
+// useStack makes the goroutine need about kilobytes of stack, so that it has
+// to grow the initial one.
+//
+//go:noinline
+func useStack(kilobytes int) byte {
+	var buffer [1024]byte
+	if kilobytes <= 1 {
+		return buffer[kilobytes]
+	}
+	buffer[0] = useStack(kilobytes - 1)
+	return buffer[0]
+}
+
+// BenchmarkGoroutine_Spawn times only the go statements, the goroutines are
+// parked until the timer is stopped. They are spawned in batches, so that
+// parked stacks do not eat all of the memory at large b.N.
+func BenchmarkGoroutine_Spawn(b *testing.B) {
+	const batch = 10000
+	for spawned := 0; spawned < b.N; spawned += batch {
+		count := min(batch, b.N-spawned)
+		start := make(chan struct{})
+		wg := &sync.WaitGroup{}
+		wg.Add(count)
+		for i := 0; i < count; i++ {
+			go func() {
+				<-start
+				wg.Done()
+			}()
+		}
+
+		b.StopTimer()
+		close(start)
+		wg.Wait()
+		b.StartTimer()
+	}
+}
+
+func BenchmarkGoroutine_SpawnExit(b *testing.B) {
+	for _, stack := range goroutineStacks {
+		b.Run("StackKB="+strconv.Itoa(stack), func(b *testing.B) {
+			wg := &sync.WaitGroup{}
+			wg.Add(b.N)
+			for i := 0; i < b.N; i++ {
+				go func() {
+					if stack > 0 {
+						useStack(stack)
+					}
+					wg.Done()
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func BenchmarkGoroutine_FanOutFanIn(b *testing.B) {
+	for _, goroutines := range []int{10, 100, 1000, 10000, 100000} {
+		for _, stack := range goroutineStacks {
+			name := "Goroutines=" + strconv.Itoa(goroutines) + "/StackKB=" + strconv.Itoa(stack)
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					wg := &sync.WaitGroup{}
+					wg.Add(goroutines)
+					for g := 0; g < goroutines; g++ {
+						go func() {
+							if stack > 0 {
+								useStack(stack)
+							}
+							wg.Done()
+						}()
+					}
+					wg.Wait()
+				}
+				reportPerEntry(b, goroutines)
+			})
+		}
+	}
+}
+
+func BenchmarkGoroutine_Gosched(b *testing.B) {
+	b.Run("Alone", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			runtime.Gosched()
+		}
+	})
+
+	// with a single P every Gosched hands the P over to the other goroutine
+	b.Run("Handoff", func(b *testing.B) {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+		rounds := b.N / 2
+		wg := &sync.WaitGroup{}
+		wg.Add(2)
+		for g := 0; g < 2; g++ {
+			go func() {
+				for i := 0; i < rounds; i++ {
+					runtime.Gosched()
+				}
+				wg.Done()
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// BenchmarkGoroutine_LockOSThread ping-pongs over an unbuffered channel, a
+// goroutine locked to its thread can't be handed over to by the scheduler
+// directly and costs a thread switch instead.
+func BenchmarkGoroutine_LockOSThread(b *testing.B) {
+	variants := []struct {
+		name string
+		ping bool
+		pong bool
+	}{
+		{"None", false, false},
+		{"One", true, false},
+		{"Both", true, true},
+	}
+
+	for _, variant := range variants {
+		b.Run(variant.name, func(b *testing.B) {
+			ping := make(chan struct{})
+			pong := make(chan struct{})
+			rounds := b.N
+			done := make(chan struct{})
+
+			go func() {
+				if variant.pong {
+					runtime.LockOSThread()
+					defer runtime.UnlockOSThread()
+				}
+				for i := 0; i < rounds; i++ {
+					<-ping
+					pong <- struct{}{}
+				}
+				close(done)
+			}()
+
+			if variant.ping {
+				runtime.LockOSThread()
+				defer runtime.UnlockOSThread()
+			}
+
+			b.ResetTimer()
+			for i := 0; i < rounds; i++ {
+				ping <- struct{}{}
+				<-pong
+			}
+			<-done
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package histogram implements an HDR-style histogram of non-negative int64
+// values. Every power of two range is split into the same number of linear
+// buckets, so a value is recorded with a relative error of less than 1/128
+// no matter how large it is, in constant memory and time.
+package histogram
+
+import "math/bits"
+
+const (
+	subBits    = 7
+	subBuckets = 1 << subBits
+
+	buckets = (63-subBits)*subBuckets + 2*subBuckets
+)
+
+type Histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+func New() *Histogram {
+	return &Histogram{counts: make([]uint64, buckets)}
+}
+
+// index returns the bucket of value: values below subBuckets have their own
+// buckets, larger ones are shifted right until subBits+1 bits remain.
+func index(value int64) int {
+	shift := max(bits.Len64(uint64(value))-subBits-1, 0)
+	return shift*subBuckets + int(value>>shift)
+}
+
+// highest returns the largest value which falls into the bucket.
+func highest(index int) int64 {
+	shift := max(index/subBuckets-1, 0)
+	mantissa := int64(index - shift*subBuckets)
+	return (mantissa+1)<<shift - 1
+}
+
+// Record adds a value, negative values are recorded as zero.
+func (histogram *Histogram) Record(value int64) {
+	value = max(value, 0)
+
+	if histogram.count == 0 || value < histogram.min {
+		histogram.min = value
+	}
+	histogram.max = max(histogram.max, value)
+
+	histogram.counts[index(value)]++
+	histogram.count++
+	histogram.sum += float64(value)
+}
+
+// Merge adds every value recorded by other.
+func (histogram *Histogram) Merge(other *Histogram) {
+	if other.count == 0 {
+		return
+	}
+
+	if histogram.count == 0 || other.min < histogram.min {
+		histogram.min = other.min
+	}
+	histogram.max = max(histogram.max, other.max)
+
+	for index, count := range other.counts {
+		histogram.counts[index] += count
+	}
+	histogram.count += other.count
+	histogram.sum += other.sum
+}
+
+func (histogram *Histogram) Count() uint64 {
+	return histogram.count
+}
+
+func (histogram *Histogram) Min() int64 {
+	return histogram.min
+}
+
+func (histogram *Histogram) Max() int64 {
+	return histogram.max
+}
+
+func (histogram *Histogram) Mean() float64 {
+	if histogram.count == 0 {
+		return 0
+	}
+
+	return histogram.sum / float64(histogram.count)
+}
+
+// Percentile returns the value below or at which the given percent of the
+// recorded values are, as the highest value of its bucket but never more
+// than the maximum recorded.
+func (histogram *Histogram) Percentile(percent float64) int64 {
+	if histogram.count == 0 {
+		return 0
+	}
+
+	rank := uint64(percent / 100 * float64(histogram.count))
+	rank = min(max(rank, 1), histogram.count)
+
+	seen := uint64(0)
+	for index, count := range histogram.counts {
+		seen += count
+		if seen >= rank {
+			return min(highest(index), histogram.max)
+		}
+	}
+
+	return histogram.max
+}
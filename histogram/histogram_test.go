@@ -0,0 +1,53 @@
+package histogram
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	values := []int64{}
+	recorded := New()
+	merged := New()
+	for part := 0; part < 100; part++ {
+		other := New()
+		for i := 0; i < 1000; i++ {
+			value := int64(random.ExpFloat64() * 1e6)
+			values = append(values, value)
+			recorded.Record(value)
+			other.Record(value)
+		}
+
+		merged.Merge(other)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] < values[j]
+	})
+
+	for _, percent := range []float64{0, 50, 90, 99, 99.9, 100} {
+		rank := max(int(percent/100*float64(len(values))), 1)
+		expected := values[rank-1]
+
+		for _, histogram := range []*Histogram{recorded, merged} {
+			value := histogram.Percentile(percent)
+			if value < expected || float64(value-expected) > float64(expected)/128+1 {
+				t.Errorf("p%v = %d, expected %d within 1/128", percent, value, expected)
+			}
+		}
+	}
+
+	if recorded.Min() != values[0] || recorded.Max() != values[len(values)-1] {
+		t.Errorf(
+			"min, max = %d, %d; expected %d, %d",
+			recorded.Min(), recorded.Max(), values[0], values[len(values)-1],
+		)
+	}
+
+	if recorded.Count() != uint64(len(values)) || merged.Count() != recorded.Count() {
+		t.Errorf("count = %d, %d; expected %d", recorded.Count(), merged.Count(), len(values))
+	}
+}
@@ -0,0 +1,74 @@
+// Package ledger holds the records the disk-write benchmarks persist.
+package ledger
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/kovetskiy/goa/uuid"
+)
+
+type Packet struct {
+	ID int64 `json:"id"`
+
+	AccountDebit  uuid.UUID `json:"account_debit" binding:"required"`
+	AccountCredit uuid.UUID `json:"account_credit" binding:"required"`
+
+	Status int64    `json:"status,omitempty"`
+	Side   int64    `json:"side"`
+	Kind   int64    `json:"kind"`
+	Market [10]byte `json:"market"`
+	Amount int64    `json:"amount"`
+	Price  int64    `json:"price"`
+
+	CreatedAt int64 `json:"created_at"`
+	UpdatedAt int64 `json:"updated_at,omitempty"`
+}
+
+// PacketSize is the length of a big-endian encoded Packet.
+var PacketSize = binary.Size(Packet{})
+
+// AppendPacket appends the big-endian encoding of the packet to buffer, the
+// same as binary.Write does but without reflection.
+func AppendPacket(buffer []byte, packet *Packet) []byte {
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(packet.ID))
+	buffer = append(buffer, packet.AccountDebit[:]...)
+	buffer = append(buffer, packet.AccountCredit[:]...)
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(packet.Status))
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(packet.Side))
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(packet.Kind))
+	buffer = append(buffer, packet.Market[:]...)
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(packet.Amount))
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(packet.Price))
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(packet.CreatedAt))
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(packet.UpdatedAt))
+
+	return buffer
+}
+
+// DecodePacket decodes a packet encoded by AppendPacket.
+func DecodePacket(data []byte, packet *Packet) error {
+	if len(data) < PacketSize {
+		return io.ErrUnexpectedEOF
+	}
+
+	next := func(size int) []byte {
+		field := data[:size]
+		data = data[size:]
+		return field
+	}
+
+	packet.ID = int64(binary.BigEndian.Uint64(next(8)))
+	copy(packet.AccountDebit[:], next(len(packet.AccountDebit)))
+	copy(packet.AccountCredit[:], next(len(packet.AccountCredit)))
+	packet.Status = int64(binary.BigEndian.Uint64(next(8)))
+	packet.Side = int64(binary.BigEndian.Uint64(next(8)))
+	packet.Kind = int64(binary.BigEndian.Uint64(next(8)))
+	copy(packet.Market[:], next(len(packet.Market)))
+	packet.Amount = int64(binary.BigEndian.Uint64(next(8)))
+	packet.Price = int64(binary.BigEndian.Uint64(next(8)))
+	packet.CreatedAt = int64(binary.BigEndian.Uint64(next(8)))
+	packet.UpdatedAt = int64(binary.BigEndian.Uint64(next(8)))
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// TestPacket_Encoding checks the hand-written encoding against binary.Write,
+// which the WAL frame format is defined by.
+func TestPacket_Encoding(t *testing.T) {
+	packet := Packet{
+		ID:        -1,
+		Status:    2,
+		Side:      3,
+		Kind:      4,
+		Amount:    5,
+		Price:     6,
+		CreatedAt: 7,
+		UpdatedAt: 8,
+	}
+	copy(packet.AccountDebit[:], "debit account id")
+	copy(packet.AccountCredit[:], "credit account i")
+	copy(packet.Market[:], "BTC/USDT")
+
+	expected := bytes.NewBuffer(nil)
+	err := binary.Write(expected, binary.BigEndian, packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if PacketSize != expected.Len() {
+		t.Fatalf("PacketSize is %d, binary.Write writes %d bytes", PacketSize, expected.Len())
+	}
+
+	encoded := AppendPacket(nil, &packet)
+	if !bytes.Equal(encoded, expected.Bytes()) {
+		t.Fatalf("AppendPacket = %x, binary.Write = %x", encoded, expected.Bytes())
+	}
+
+	decoded := Packet{}
+	err = DecodePacket(expected.Bytes(), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != packet {
+		t.Fatalf("DecodePacket = %+v, expected %+v", decoded, packet)
+	}
+}
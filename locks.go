@@ -0,0 +1,73 @@
+package benchmarks
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// rwLocker is the common surface of the locks under benchmark; the exclusive
+// locks take the lock for reads as well.
+type rwLocker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+type lockFactory struct {
+	name string
+	new  func() rwLocker
+}
+
+func lockFactories() []lockFactory {
+	return []lockFactory{
+		{name: "Mutex", new: func() rwLocker { return &exclusiveLock{Locker: &sync.Mutex{}} }},
+		{name: "RWMutex", new: func() rwLocker { return &sync.RWMutex{} }},
+		{name: "SpinLock", new: func() rwLocker { return &exclusiveLock{Locker: &spinLock{}} }},
+		{name: "ChanSemaphore", new: func() rwLocker { return &exclusiveLock{Locker: newChanSemaphore()} }},
+	}
+}
+
+type exclusiveLock struct {
+	sync.Locker
+}
+
+func (lock *exclusiveLock) RLock() {
+	lock.Lock()
+}
+
+func (lock *exclusiveLock) RUnlock() {
+	lock.Unlock()
+}
+
+// spinLock busy-waits for the lock, yielding the processor between attempts
+// so that it does not starve the holder when goroutines outnumber CPUs.
+type spinLock struct {
+	state atomic.Int32
+}
+
+func (lock *spinLock) Lock() {
+	for !lock.state.CompareAndSwap(0, 1) {
+		runtime.Gosched()
+	}
+}
+
+func (lock *spinLock) Unlock() {
+	lock.state.Store(0)
+}
+
+// chanSemaphore is a channel with a single slot used as a lock.
+type chanSemaphore chan struct{}
+
+func newChanSemaphore() chanSemaphore {
+	return make(chanSemaphore, 1)
+}
+
+func (semaphore chanSemaphore) Lock() {
+	semaphore <- struct{}{}
+}
+
+func (semaphore chanSemaphore) Unlock() {
+	<-semaphore
+}
@@ -0,0 +1,189 @@
+package benchmarks
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var lockGoroutines = []int{1, 2, 4, 8, 16, 64}
+
+// runContended splits b.N operations between the given number of goroutines,
+// op receives the index of the operation.
+func runContended(b *testing.B, goroutines int, op func(i int)) {
+	wg := &sync.WaitGroup{}
+	b.ResetTimer()
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < b.N; i += goroutines {
+				op(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// BenchmarkLock_Map guards a std map the same way the WithLock map benchmarks
+// do, writes percents of the operations write to it and the others read.
+func BenchmarkLock_Map(b *testing.B) {
+	for _, mix := range mapContentionMixes {
+		b.Run(mix.name, func(b *testing.B) {
+			for _, factory := range lockFactories() {
+				b.Run(factory.name, func(b *testing.B) {
+					for _, goroutines := range lockGoroutines {
+						b.Run("Goroutines="+strconv.Itoa(goroutines), func(b *testing.B) {
+							lock := factory.new()
+							table := map[int]int64{}
+							for k := 0; k < 1024; k++ {
+								table[k] = 1
+							}
+
+							var sink atomic.Int64
+							runContended(b, goroutines, func(i int) {
+								if uint64(i%100) < mix.writes {
+									lock.Lock()
+									table[i%1024] = int64(i)
+									lock.Unlock()
+								} else {
+									lock.RLock()
+									value := table[i%1024]
+									lock.RUnlock()
+									if value < 0 {
+										sink.Add(value)
+									}
+								}
+							})
+						})
+					}
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkLock_Counter(b *testing.B) {
+	counters := []struct {
+		name string
+		new  func() (increment func(), load func() int64)
+	}{
+		{"Mutex", func() (func(), func() int64) {
+			value := int64(0)
+			mutex := &sync.Mutex{}
+			return func() {
+					mutex.Lock()
+					value++
+					mutex.Unlock()
+				}, func() int64 {
+					return value
+				}
+		}},
+		{"AtomicAdd", func() (func(), func() int64) {
+			var value atomic.Int64
+			return func() {
+				value.Add(1)
+			}, value.Load
+		}},
+		{"AtomicCAS", func() (func(), func() int64) {
+			var value atomic.Int64
+			return func() {
+				for {
+					current := value.Load()
+					if value.CompareAndSwap(current, current+1) {
+						return
+					}
+				}
+			}, value.Load
+		}},
+	}
+
+	for _, counter := range counters {
+		b.Run(counter.name, func(b *testing.B) {
+			for _, goroutines := range lockGoroutines {
+				b.Run("Goroutines="+strconv.Itoa(goroutines), func(b *testing.B) {
+					increment, load := counter.new()
+					runContended(b, goroutines, func(int) {
+						increment()
+					})
+					if load() != int64(b.N) {
+						b.Fatalf("counter is %d, expected %d", load(), b.N)
+					}
+				})
+			}
+		})
+	}
+}
+
+// broadcastRound is closed to wake the waiters of a round, who then move on
+// to the next one, so every waiter sees every round exactly once.
+type broadcastRound struct {
+	closed chan struct{}
+	next   *broadcastRound
+}
+
+// BenchmarkLock_Broadcast wakes up all waiting goroutines and waits until
+// every one of them has noticed, once with sync.Cond and once by closing a
+// channel.
+func BenchmarkLock_Broadcast(b *testing.B) {
+	for _, waiters := range lockGoroutines {
+		b.Run("Cond/Waiters="+strconv.Itoa(waiters), func(b *testing.B) {
+			mutex := &sync.Mutex{}
+			cond := sync.NewCond(mutex)
+			generation := 0
+			woken := &sync.WaitGroup{}
+			rounds := b.N
+
+			for w := 0; w < waiters; w++ {
+				go func() {
+					seen := 0
+					mutex.Lock()
+					for seen < rounds {
+						for generation == seen {
+							cond.Wait()
+						}
+						seen = generation
+						woken.Done()
+					}
+					mutex.Unlock()
+				}()
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				woken.Add(waiters)
+				mutex.Lock()
+				generation++
+				mutex.Unlock()
+				cond.Broadcast()
+				woken.Wait()
+			}
+		})
+
+		b.Run("ChanClose/Waiters="+strconv.Itoa(waiters), func(b *testing.B) {
+			round := &broadcastRound{closed: make(chan struct{})}
+			woken := &sync.WaitGroup{}
+			rounds := b.N
+
+			for w := 0; w < waiters; w++ {
+				go func(round *broadcastRound) {
+					for i := 0; i < rounds; i++ {
+						<-round.closed
+						round = round.next
+						woken.Done()
+					}
+				}(round)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				woken.Add(waiters)
+				round.next = &broadcastRound{closed: make(chan struct{})}
+				close(round.closed)
+				round = round.next
+				woken.Wait()
+			}
+		})
+	}
+}
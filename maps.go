@@ -0,0 +1,476 @@
+package benchmarks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	intintmap "github.com/brentp/intintmap"
+	christomic "github.com/chris-tomich/go-fast-hashmap"
+	cornelk "github.com/cornelk/hashmap"
+	lfmap "github.com/fastgeert/go-lfmap"
+	"github.com/kovetskiy/benchmarks-go/openmap"
+	"github.com/kovetskiy/benchmarks-go/shardmap"
+	"github.com/kovetskiy/benchmarks-go/swissmap"
+	suncat "github.com/suncat2000/hashmap"
+)
+
+// Map is the common surface of every map implementation under benchmark.
+type Map[K comparable] interface {
+	Set(key K, value int64)
+	Get(key K) (int64, bool)
+}
+
+// mapDeleter is implemented by the adapters whose underlying map supports
+// removal.
+type mapDeleter[K comparable] interface {
+	Delete(key K)
+}
+
+// mapRanger is implemented by the adapters whose underlying map can be
+// iterated. Range calls fn for every entry until it returns false; see the
+// README for which implementations iterate over a consistent snapshot.
+type mapRanger[K comparable] interface {
+	Range(fn func(key K, value int64) bool)
+}
+
+type mapFactory[K comparable] struct {
+	name string
+	// presize tells whether the implementation takes a capacity hint.
+	presize bool
+	// concurrent tells whether the implementation is safe for concurrent use.
+	concurrent bool
+	new        func(hint int) Map[K]
+}
+
+// mapFactories returns every implementation which supports keys of type K.
+func mapFactories[K comparable]() []mapFactory[K] {
+	factories := []mapFactory[K]{
+		{name: "StdMap_Typed", presize: true, new: newStdMap[K]},
+		{name: "StdMap_WithLock_Typed", presize: true, concurrent: true, new: newLockedStdMap[K]},
+		{name: "StdMap_Interface", presize: true, new: newInterfaceStdMap[K]},
+		{name: "SyncMap", concurrent: true, new: newSyncMap[K]},
+		{name: "ShardMap_Generic", presize: true, concurrent: true, new: newShardMap[K]},
+		{name: "OpenMap_Generic", presize: true, new: newOpenMap[K]},
+		{name: "SwissMap_Generic", presize: true, new: newSwissMap[K]},
+	}
+
+	// cornelk and suncat hash keys with a type switch over scalar types
+	// and strings, everything else is rejected.
+	switch any(*new(K)).(type) {
+	case int64:
+		factories = append(
+			factories,
+			mapFactory[K]{name: "CornelkHashmap", presize: true, concurrent: true, new: newCornelkMap[K]},
+			mapFactory[K]{name: "Intintmap", presize: true, new: specialize[K](newIntintMap)},
+			mapFactory[K]{name: "SuncatHashmap", presize: true, concurrent: true, new: newSuncatMap[K]},
+		)
+	case string:
+		factories = append(
+			factories,
+			mapFactory[K]{name: "CornelkHashmap", presize: true, concurrent: true, new: newCornelkMap[K]},
+			mapFactory[K]{name: "SuncatHashmap", presize: true, concurrent: true, new: newSuncatMap[K]},
+			mapFactory[K]{name: "ChrisTomichHashmap", presize: true, concurrent: true, new: specialize[K](newChrisTomichMap)},
+			mapFactory[K]{name: "LFMap", concurrent: true, new: specialize[K](newLFMap)},
+		)
+	}
+
+	return factories
+}
+
+// genericMapFactories returns the generic containers instantiated both with
+// concrete types and with interface{}, next to their std map counterparts.
+func genericMapFactories[K comparable]() []mapFactory[K] {
+	return []mapFactory[K]{
+		{name: "StdMap_Typed", presize: true, new: newStdMap[K]},
+		{name: "StdMap_Interface", presize: true, new: newInterfaceStdMap[K]},
+		{name: "ShardMap_Generic", presize: true, concurrent: true, new: newShardMap[K]},
+		{name: "ShardMap_Interface", presize: true, concurrent: true, new: newInterfaceShardMap[K]},
+		{name: "OpenMap_Generic", presize: true, new: newOpenMap[K]},
+		{name: "OpenMap_Interface", presize: true, new: newInterfaceOpenMap[K]},
+	}
+}
+
+// specialize adapts the constructor of an implementation with a fixed key
+// type to the generic registry, it must be used only when K is T.
+func specialize[K, T comparable](new func(int) Map[T]) func(int) Map[K] {
+	return func(hint int) Map[K] {
+		return any(new(hint)).(Map[K])
+	}
+}
+
+// uuidKey mimics the account identifiers used as keys in the ledger.
+type uuidKey [16]byte
+
+type structKey struct {
+	Account int64
+	Kind    int32
+	Side    int32
+}
+
+func createIntKeys(max int) []int64 {
+	keys := make([]int64, max)
+	for i := 0; i < max; i++ {
+		keys[i] = int64(i)
+	}
+	return keys
+}
+
+func createStringKeys(max int) []string {
+	keys := make([]string, max)
+	for i := 0; i < max; i++ {
+		keys[i] = strconv.Itoa(i)
+	}
+	return keys
+}
+
+func createLongStringKeys(max int) []string {
+	keys := make([]string, max)
+	for i := 0; i < max; i++ {
+		keys[i] = fmt.Sprintf("account:%056d", i)
+	}
+	return keys
+}
+
+func createUUIDKeys(max int) []uuidKey {
+	random := rand.New(rand.NewSource(1))
+	keys := make([]uuidKey, max)
+	for i := 0; i < max; i++ {
+		random.Read(keys[i][:8])
+		binary.BigEndian.PutUint64(keys[i][8:], uint64(i))
+	}
+	return keys
+}
+
+func createStructKeys(max int) []structKey {
+	keys := make([]structKey, max)
+	for i := 0; i < max; i++ {
+		keys[i] = structKey{Account: int64(i / 4), Kind: int32(i % 2), Side: int32(i % 4 / 2)}
+	}
+	return keys
+}
+
+func orDefault(hint, fallback int) int {
+	if hint == 0 {
+		return fallback
+	}
+	return hint
+}
+
+type stdMap[K comparable] map[K]int64
+
+func newStdMap[K comparable](hint int) Map[K] {
+	return stdMap[K](make(map[K]int64, hint))
+}
+
+func (table stdMap[K]) Set(key K, value int64) {
+	table[key] = value
+}
+
+func (table stdMap[K]) Get(key K) (int64, bool) {
+	value, ok := table[key]
+	return value, ok
+}
+
+func (table stdMap[K]) Delete(key K) {
+	delete(table, key)
+}
+
+func (table stdMap[K]) Range(fn func(key K, value int64) bool) {
+	for key, value := range table {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+type lockedStdMap[K comparable] struct {
+	mutex sync.Mutex
+	table map[K]int64
+}
+
+func newLockedStdMap[K comparable](hint int) Map[K] {
+	return &lockedStdMap[K]{table: make(map[K]int64, hint)}
+}
+
+func (table *lockedStdMap[K]) Set(key K, value int64) {
+	table.mutex.Lock()
+	table.table[key] = value
+	table.mutex.Unlock()
+}
+
+func (table *lockedStdMap[K]) Get(key K) (int64, bool) {
+	table.mutex.Lock()
+	value, ok := table.table[key]
+	table.mutex.Unlock()
+	return value, ok
+}
+
+func (table *lockedStdMap[K]) Delete(key K) {
+	table.mutex.Lock()
+	delete(table.table, key)
+	table.mutex.Unlock()
+}
+
+// Range holds the lock for the whole iteration, so fn must not call back into
+// the table.
+func (table *lockedStdMap[K]) Range(fn func(key K, value int64) bool) {
+	table.mutex.Lock()
+	defer table.mutex.Unlock()
+	for key, value := range table.table {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+type interfaceStdMap[K comparable] map[interface{}]interface{}
+
+func newInterfaceStdMap[K comparable](hint int) Map[K] {
+	return interfaceStdMap[K](make(map[interface{}]interface{}, hint))
+}
+
+func (table interfaceStdMap[K]) Set(key K, value int64) {
+	table[key] = value
+}
+
+func (table interfaceStdMap[K]) Get(key K) (int64, bool) {
+	value, ok := table[key]
+	if !ok {
+		return 0, false
+	}
+	return value.(int64), true
+}
+
+func (table interfaceStdMap[K]) Delete(key K) {
+	delete(table, key)
+}
+
+func (table interfaceStdMap[K]) Range(fn func(key K, value int64) bool) {
+	for key, value := range table {
+		if !fn(key.(K), value.(int64)) {
+			return
+		}
+	}
+}
+
+type syncMap[K comparable] struct {
+	table sync.Map
+}
+
+func newSyncMap[K comparable](int) Map[K] {
+	return &syncMap[K]{}
+}
+
+func (table *syncMap[K]) Set(key K, value int64) {
+	table.table.Store(key, value)
+}
+
+func (table *syncMap[K]) Get(key K) (int64, bool) {
+	value, ok := table.table.Load(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int64), true
+}
+
+func (table *syncMap[K]) Delete(key K) {
+	table.table.Delete(key)
+}
+
+func (table *syncMap[K]) Range(fn func(key K, value int64) bool) {
+	table.table.Range(func(key, value interface{}) bool {
+		return fn(key.(K), value.(int64))
+	})
+}
+
+type cornelkMap[K comparable] struct {
+	table *cornelk.HashMap
+}
+
+func newCornelkMap[K comparable](hint int) Map[K] {
+	return cornelkMap[K]{table: cornelk.New(uintptr(orDefault(hint, cornelk.DefaultSize)))}
+}
+
+func (table cornelkMap[K]) Set(key K, value int64) {
+	table.table.Set(key, value)
+}
+
+func (table cornelkMap[K]) Get(key K) (int64, bool) {
+	value, ok := table.table.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int64), true
+}
+
+func (table cornelkMap[K]) Delete(key K) {
+	table.table.Del(key)
+}
+
+// Range drains the iterator after fn returns false, otherwise the goroutine
+// feeding it would leak, so stopping early does not make it cheaper.
+func (table cornelkMap[K]) Range(fn func(key K, value int64) bool) {
+	items := table.table.Iter()
+	for item := range items {
+		if !fn(item.Key.(K), item.Value.(int64)) {
+			break
+		}
+	}
+	for range items {
+	}
+}
+
+type intintMap struct {
+	table *intintmap.Map
+}
+
+func newIntintMap(hint int) Map[int64] {
+	return intintMap{table: intintmap.New(orDefault(hint, 1), 0.6)}
+}
+
+func (table intintMap) Set(key int64, value int64) {
+	table.table.Put(key, value)
+}
+
+func (table intintMap) Get(key int64) (int64, bool) {
+	return table.table.Get(key)
+}
+
+func (table intintMap) Delete(key int64) {
+	table.table.Del(key)
+}
+
+// Range drains the channel after fn returns false, the same as cornelk.
+func (table intintMap) Range(fn func(key int64, value int64) bool) {
+	items := table.table.Items()
+	for item := range items {
+		if !fn(item[0], item[1]) {
+			break
+		}
+	}
+	for range items {
+	}
+}
+
+type suncatMap[K comparable] struct {
+	table *suncat.HashMap
+}
+
+func newSuncatMap[K comparable](hint int) Map[K] {
+	return suncatMap[K]{table: suncat.NewHashMap(orDefault(hint, 16))}
+}
+
+func (table suncatMap[K]) Set(key K, value int64) {
+	table.table.Set(key, value)
+}
+
+func (table suncatMap[K]) Get(key K) (int64, bool) {
+	value, ok := table.table.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int64), true
+}
+
+type chrisTomichMap struct {
+	table *christomic.HashMap
+}
+
+func newChrisTomichMap(hint int) Map[string] {
+	return chrisTomichMap{table: christomic.New(uint64(orDefault(hint, 16)))}
+}
+
+func (table chrisTomichMap) Set(key string, value int64) {
+	table.table.Set(key, value)
+}
+
+func (table chrisTomichMap) Get(key string) (int64, bool) {
+	value, ok := table.table.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int64), true
+}
+
+type lfMap struct {
+	table *lfmap.LFmap
+}
+
+func newLFMap(int) Map[string] {
+	return lfMap{table: lfmap.NewLFmap()}
+}
+
+func (table lfMap) Set(key string, value int64) {
+	table.table.Set(key, value)
+}
+
+func (table lfMap) Get(key string) (int64, bool) {
+	value, ok := table.table.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int64), true
+}
+
+func newShardMap[K comparable](hint int) Map[K] {
+	return shardmap.New[K, int64](hint)
+}
+
+type interfaceShardMap[K comparable] struct {
+	table *shardmap.Map[interface{}, interface{}]
+}
+
+func newInterfaceShardMap[K comparable](hint int) Map[K] {
+	return interfaceShardMap[K]{table: shardmap.New[interface{}, interface{}](hint)}
+}
+
+func (table interfaceShardMap[K]) Set(key K, value int64) {
+	table.table.Set(key, value)
+}
+
+func (table interfaceShardMap[K]) Get(key K) (int64, bool) {
+	value, ok := table.table.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int64), true
+}
+
+func (table interfaceShardMap[K]) Delete(key K) {
+	table.table.Delete(key)
+}
+
+func newOpenMap[K comparable](hint int) Map[K] {
+	return openmap.New[K, int64](hint)
+}
+
+type interfaceOpenMap[K comparable] struct {
+	table *openmap.Map[interface{}, interface{}]
+}
+
+func newInterfaceOpenMap[K comparable](hint int) Map[K] {
+	return interfaceOpenMap[K]{table: openmap.New[interface{}, interface{}](hint)}
+}
+
+func (table interfaceOpenMap[K]) Set(key K, value int64) {
+	table.table.Set(key, value)
+}
+
+func (table interfaceOpenMap[K]) Get(key K) (int64, bool) {
+	value, ok := table.table.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int64), true
+}
+
+func (table interfaceOpenMap[K]) Delete(key K) {
+	table.table.Delete(key)
+}
+
+func newSwissMap[K comparable](hint int) Map[K] {
+	return swissmap.New[K, int64](hint)
+}
@@ -0,0 +1,236 @@
+package benchmarks
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+const (
+	conformanceKeys    = 1000
+	conformanceOps     = 100000
+	conformanceWorkers = 8
+)
+
+// checkMapModel applies a random sequence of operations both to the
+// implementation and to a reference map and reports the first divergence.
+func checkMapModel[K comparable](factory mapFactory[K], keys []K, seed int64, ops int) error {
+	random := rand.New(rand.NewSource(seed))
+	table := factory.new(0)
+	deleter, canDelete := table.(mapDeleter[K])
+	model := map[K]int64{}
+
+	for op := 0; op < ops; op++ {
+		key := keys[random.Intn(len(keys))]
+		switch random.Intn(3) {
+		case 0:
+			value := random.Int63()
+			table.Set(key, value)
+			model[key] = value
+		case 1:
+			value, ok := table.Get(key)
+			expected, expectedOk := model[key]
+			if value != expected || ok != expectedOk {
+				return fmt.Errorf(
+					"op %d: Get(%v) = %d, %t; expected %d, %t",
+					op, key, value, ok, expected, expectedOk,
+				)
+			}
+		case 2:
+			if canDelete {
+				deleter.Delete(key)
+				delete(model, key)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		value, ok := table.Get(key)
+		expected, expectedOk := model[key]
+		if value != expected || ok != expectedOk {
+			return fmt.Errorf(
+				"final: Get(%v) = %d, %t; expected %d, %t",
+				key, value, ok, expected, expectedOk,
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkMapConcurrent runs workers which own disjoint key ranges, verify their
+// own writes while reading everybody else's keys, and all race on a single
+// hot key. Afterwards the table must match the union of the workers' models
+// and the hot key must hold the last value written by one of the workers.
+func checkMapConcurrent[K comparable](factory mapFactory[K], keys []K, seed int64, ops int) error {
+	table := factory.new(0)
+	hot := keys[0]
+	keys = keys[1:]
+
+	models := make([]map[K]int64, conformanceWorkers)
+	lasts := make([]int64, conformanceWorkers)
+	errs := make(chan error, conformanceWorkers)
+
+	wg := &sync.WaitGroup{}
+	for worker := 0; worker < conformanceWorkers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			random := rand.New(rand.NewSource(seed + int64(worker)))
+			model := map[K]int64{}
+			for op := 0; op < ops/conformanceWorkers; op++ {
+				index := random.Intn(len(keys))
+				key := keys[index]
+				if index%conformanceWorkers != worker {
+					table.Get(key)
+					continue
+				}
+
+				switch random.Intn(3) {
+				case 0:
+					value := random.Int63()
+					table.Set(key, value)
+					model[key] = value
+				case 1:
+					value, ok := table.Get(key)
+					expected, expectedOk := model[key]
+					if value != expected || ok != expectedOk {
+						errs <- fmt.Errorf(
+							"worker %d op %d: Get(%v) = %d, %t; expected %d, %t",
+							worker, op, key, value, ok, expected, expectedOk,
+						)
+						return
+					}
+				case 2:
+					lasts[worker] = int64(worker)<<32 | int64(op)
+					table.Set(hot, lasts[worker])
+				}
+			}
+
+			models[worker] = model
+		}(worker)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+
+	for index, key := range keys {
+		value, ok := table.Get(key)
+		expected, expectedOk := models[index%conformanceWorkers][key]
+		if value != expected || ok != expectedOk {
+			return fmt.Errorf(
+				"final: Get(%v) = %d, %t; expected %d, %t",
+				key, value, ok, expected, expectedOk,
+			)
+		}
+	}
+
+	value, _ := table.Get(hot)
+	for _, last := range lasts {
+		if value == last {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("hot key holds %d which is not the last write of any worker", value)
+}
+
+func checkMapConformance[K comparable](factory mapFactory[K], keys []K, ops int) error {
+	err := checkMapModel(factory, keys, 1, ops)
+	if err != nil {
+		return fmt.Errorf("model: %s", err)
+	}
+
+	if factory.concurrent {
+		err := checkMapConcurrent(factory, keys, 1, ops)
+		if err != nil {
+			return fmt.Errorf("concurrent: %s", err)
+		}
+	}
+
+	return nil
+}
+
+var (
+	nonconformingMaps      = map[string]error{}
+	nonconformingMapsMutex = sync.Mutex{}
+)
+
+// flagNonconforming marks the benchmark results of an implementation which
+// does not pass the conformance checks with the given key set, so that its
+// numbers are not taken at face value. Results are cached per implementation
+// and key set.
+func flagNonconforming[K comparable](b *testing.B, keySet string, factory mapFactory[K], keys []K) {
+	id := factory.name + "/" + keySet
+
+	nonconformingMapsMutex.Lock()
+	err, checked := nonconformingMaps[id]
+	if !checked {
+		if len(keys) > conformanceKeys {
+			keys = keys[:conformanceKeys]
+		}
+		err = checkMapConformance(factory, keys, conformanceOps/10)
+		nonconformingMaps[id] = err
+	}
+	nonconformingMapsMutex.Unlock()
+
+	if err != nil {
+		b.Logf("%s is nonconforming with %s keys: %s", factory.name, keySet, err)
+
+		// b.ResetTimer drops the metrics reported so far, so it is reported
+		// once the benchmark is done
+		b.Cleanup(func() {
+			b.ReportMetric(1, "nonconforming")
+		})
+	}
+}
+
+// flagNonconformingMap is flagNonconforming for benchmarks which use an
+// implementation directly instead of through its factory.
+func flagNonconformingMap[K comparable](b *testing.B, keySet string, name string, keys []K) {
+	for _, factory := range mapFactories[K]() {
+		if factory.name == name {
+			flagNonconforming(b, keySet, factory, keys)
+			return
+		}
+	}
+
+	b.Fatalf("no map factory named %s", name)
+}
+
+// testMapConformance reports the implementations which fail the conformance
+// checks without failing the run, third-party maps are known to and their
+// benchmarks are flagged by flagNonconforming instead.
+func testMapConformance[K comparable](t *testing.T, name string, keys []K) {
+	ops := conformanceOps
+	if testing.Short() {
+		ops /= 10
+	}
+
+	t.Run(name, func(t *testing.T) {
+		for _, factory := range mapFactories[K]() {
+			t.Run(factory.name, func(t *testing.T) {
+				t.Parallel()
+
+				err := checkMapConformance(factory, keys, ops)
+				if err != nil {
+					t.Logf("%s is nonconforming with %s keys: %s", factory.name, name, err)
+				}
+			})
+		}
+	})
+}
+
+func TestMaps_Conformance(t *testing.T) {
+	testMapConformance(t, "Int64", createIntKeys(conformanceKeys))
+	testMapConformance(t, "String_Short", createStringKeys(conformanceKeys))
+	testMapConformance(t, "String_Long", createLongStringKeys(conformanceKeys))
+	testMapConformance(t, "UUID", createUUIDKeys(conformanceKeys))
+	testMapConformance(t, "Struct", createStructKeys(conformanceKeys))
+}
@@ -0,0 +1,410 @@
+package benchmarks
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	intintmap "github.com/brentp/intintmap"
+	"github.com/kovetskiy/benchmarks-go/histogram"
+	"github.com/kovetskiy/benchmarks-go/shardmap"
+)
+
+var mapSweepSizes = []int{100, 1000, 10000, 100000, 1000000, 10000000}
+
+var mapSweepHints = []struct {
+	name string
+	hint func(size int) int
+}{
+	{"0", func(int) int { return 0 }},
+	{"Exact", func(size int) int { return size }},
+	{"2x", func(size int) int { return size * 2 }},
+}
+
+func reportPerEntry(b *testing.B, entries int) {
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(entries), "ns/entry")
+}
+
+func benchmarkMapFill[K comparable](b *testing.B, factory mapFactory[K], keys []K, hint int) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table := factory.new(hint)
+		for _, key := range keys {
+			table.Set(key, 1)
+		}
+	}
+	reportPerEntry(b, len(keys))
+}
+
+// cachedKeys memoizes key sets so that large sets are built only when a
+// benchmark that needs them is actually selected.
+func cachedKeys[K any](createKeys func(int) []K) func(int) []K {
+	cache := map[int][]K{}
+	return func(size int) []K {
+		keys, ok := cache[size]
+		if !ok {
+			keys = createKeys(size)
+			cache[size] = keys
+		}
+		return keys
+	}
+}
+
+func benchmarkMapSweep[K comparable](b *testing.B, keySet string, factories []mapFactory[K], createKeys func(int) []K) {
+	keys := cachedKeys(createKeys)
+	for _, factory := range factories {
+		b.Run(factory.name, func(b *testing.B) {
+			for _, size := range mapSweepSizes {
+				for _, hint := range mapSweepHints {
+					if !factory.presize && hint.name != "0" {
+						continue
+					}
+					b.Run("Size="+strconv.Itoa(size)+"/Hint="+hint.name, func(b *testing.B) {
+						flagNonconforming(b, keySet, factory, keys(size))
+						benchmarkMapFill(b, factory, keys(size), hint.hint(size))
+					})
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMaps_Sweep_Fill(b *testing.B) {
+	b.Run("Int64", func(b *testing.B) {
+		benchmarkMapSweep(b, "Int64", mapFactories[int64](), createIntKeys)
+	})
+	b.Run("String_Short", func(b *testing.B) {
+		benchmarkMapSweep(b, "String_Short", mapFactories[string](), createStringKeys)
+	})
+}
+
+func benchmarkMapGet[K comparable](b *testing.B, factory mapFactory[K], keys []K) {
+	table := factory.new(0)
+	for _, key := range keys {
+		table.Set(key, 1)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			table.Get(key)
+		}
+	}
+	reportPerEntry(b, len(keys))
+}
+
+// benchmarkMapKeyType runs the workload against every given implementation.
+// Keys are built before the timer starts, so the conversion cost is excluded
+// for all implementations alike.
+func benchmarkMapKeyType[K comparable](
+	b *testing.B,
+	name string,
+	factories []mapFactory[K],
+	keys []K,
+	workload func(*testing.B, mapFactory[K], []K),
+) {
+	b.Run(name, func(b *testing.B) {
+		for _, factory := range factories {
+			b.Run(factory.name, func(b *testing.B) {
+				flagNonconforming(b, name, factory, keys)
+				workload(b, factory, keys)
+			})
+		}
+	})
+}
+
+func benchmarkMapFill10K[K comparable](b *testing.B, factory mapFactory[K], keys []K) {
+	benchmarkMapFill(b, factory, keys, 0)
+}
+
+func BenchmarkMaps_KeyTypes_Fill10K(b *testing.B) {
+	benchmarkMapKeyType(b, "Int64", mapFactories[int64](), createIntKeys(10000), benchmarkMapFill10K[int64])
+	benchmarkMapKeyType(b, "String_Short", mapFactories[string](), createStringKeys(10000), benchmarkMapFill10K[string])
+	benchmarkMapKeyType(b, "String_Long", mapFactories[string](), createLongStringKeys(10000), benchmarkMapFill10K[string])
+	benchmarkMapKeyType(b, "UUID", mapFactories[uuidKey](), createUUIDKeys(10000), benchmarkMapFill10K[uuidKey])
+	benchmarkMapKeyType(b, "Struct", mapFactories[structKey](), createStructKeys(10000), benchmarkMapFill10K[structKey])
+}
+
+func BenchmarkMaps_KeyTypes_Get10K(b *testing.B) {
+	benchmarkMapKeyType(b, "Int64", mapFactories[int64](), createIntKeys(10000), benchmarkMapGet[int64])
+	benchmarkMapKeyType(b, "String_Short", mapFactories[string](), createStringKeys(10000), benchmarkMapGet[string])
+	benchmarkMapKeyType(b, "String_Long", mapFactories[string](), createLongStringKeys(10000), benchmarkMapGet[string])
+	benchmarkMapKeyType(b, "UUID", mapFactories[uuidKey](), createUUIDKeys(10000), benchmarkMapGet[uuidKey])
+	benchmarkMapKeyType(b, "Struct", mapFactories[structKey](), createStructKeys(10000), benchmarkMapGet[structKey])
+}
+
+func BenchmarkMaps_Generic_Fill10K(b *testing.B) {
+	benchmarkMapKeyType(b, "Int64", genericMapFactories[int64](), createIntKeys(10000), benchmarkMapFill10K[int64])
+	benchmarkMapKeyType(b, "String_Short", genericMapFactories[string](), createStringKeys(10000), benchmarkMapFill10K[string])
+}
+
+func BenchmarkMaps_Generic_Get10K(b *testing.B) {
+	benchmarkMapKeyType(b, "Int64", genericMapFactories[int64](), createIntKeys(10000), benchmarkMapGet[int64])
+	benchmarkMapKeyType(b, "String_Short", genericMapFactories[string](), createStringKeys(10000), benchmarkMapGet[string])
+}
+
+// xorshift is a cheap per-goroutine random source, so that picking keys in
+// parallel benchmarks neither dominates the timing nor contends on a lock.
+type xorshift uint64
+
+func (x *xorshift) next() uint64 {
+	*x ^= *x << 13
+	*x ^= *x >> 7
+	*x ^= *x << 17
+	return uint64(*x)
+}
+
+var mapContentionMixes = []struct {
+	name   string
+	writes uint64
+}{
+	{"ReadHeavy", 10},
+	{"Mixed", 50},
+	{"WriteHeavy", 90},
+}
+
+func concurrentMaps[K comparable](factories []mapFactory[K]) []mapFactory[K] {
+	concurrent := []mapFactory[K]{}
+	for _, factory := range factories {
+		if factory.concurrent {
+			concurrent = append(concurrent, factory)
+		}
+	}
+	return concurrent
+}
+
+// benchmarkMapContention runs Get and Set, writes percents of the operations
+// being Set, on random keys of a prefilled table from GOMAXPROCS goroutines.
+func benchmarkMapContention[K comparable](b *testing.B, factory mapFactory[K], keys []K, writes uint64) {
+	table := factory.new(len(keys))
+	for _, key := range keys {
+		table.Set(key, 1)
+	}
+
+	var workers atomic.Uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		random := xorshift(workers.Add(1) * 0x9e3779b97f4a7c15)
+		for pb.Next() {
+			value := random.next()
+			key := keys[value%uint64(len(keys))]
+			if value>>32%100 < writes {
+				table.Set(key, 1)
+			} else {
+				table.Get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkMaps_Contention(b *testing.B) {
+	keys := createIntKeys(10000)
+	for _, mix := range mapContentionMixes {
+		b.Run(mix.name, func(b *testing.B) {
+			for _, factory := range concurrentMaps(mapFactories[int64]()) {
+				b.Run(factory.name, func(b *testing.B) {
+					flagNonconforming(b, "Int64", factory, keys)
+					benchmarkMapContention(b, factory, keys, mix.writes)
+				})
+			}
+		})
+	}
+}
+
+// fibonacciHash spreads sequential int keys over the high bits, which are the
+// ones shardmap picks shards by.
+func fibonacciHash(key int64) uint64 {
+	return uint64(key) * 11400714819323198485
+}
+
+var shardMapShards = []int{1, 2, 4, 8, 16, 32, 64, 128, 256, 1024}
+
+// BenchmarkMaps_ShardMap_Shards looks for the shard count sweet spot, run it
+// with -cpu or cmd/benchscale to see how it moves with the number of cores.
+func BenchmarkMaps_ShardMap_Shards(b *testing.B) {
+	keys := createIntKeys(10000)
+	hashes := []struct {
+		name string
+		hash func(key int64) uint64
+	}{
+		{"Maphash", nil},
+		{"Fibonacci", fibonacciHash},
+	}
+
+	for _, hash := range hashes {
+		for _, shards := range shardMapShards {
+			factory := mapFactory[int64]{
+				name:       fmt.Sprintf("ShardMap_%s_%d", hash.name, shards),
+				presize:    true,
+				concurrent: true,
+				new: func(hint int) Map[int64] {
+					return shardmap.NewWithConfig[int64, int64](hint, shardmap.Config[int64]{
+						Shards: shards,
+						Hash:   hash.hash,
+					})
+				},
+			}
+
+			for _, mix := range mapContentionMixes {
+				name := fmt.Sprintf("Hash=%s/Shards=%d/%s", hash.name, shards, mix.name)
+				b.Run(name, func(b *testing.B) {
+					flagNonconforming(b, "Int64", factory, keys)
+					benchmarkMapContention(b, factory, keys, mix.writes)
+				})
+			}
+		}
+	}
+}
+
+var mapRangeSink int64
+
+func rangingMaps[K comparable](factories []mapFactory[K]) []mapFactory[K] {
+	ranging := []mapFactory[K]{}
+	for _, factory := range factories {
+		if _, ok := factory.new(0).(mapRanger[K]); ok {
+			ranging = append(ranging, factory)
+		}
+	}
+	return ranging
+}
+
+// benchmarkMapRange ranges over a prefilled table, stopping after limit
+// entries, and reports the cost per visited entry.
+func benchmarkMapRange[K comparable](b *testing.B, factory mapFactory[K], keys []K, limit int) {
+	table := factory.new(len(keys))
+	for _, key := range keys {
+		table.Set(key, 1)
+	}
+
+	ranger := table.(mapRanger[K])
+	visited := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entries := 0
+		ranger.Range(func(key K, value int64) bool {
+			mapRangeSink += value
+			entries++
+			return entries < limit
+		})
+		visited += entries
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(visited), "ns/entry")
+}
+
+// benchmarkMapRangeMutating ranges over a table which another goroutine keeps
+// overwriting.
+func benchmarkMapRangeMutating[K comparable](b *testing.B, factory mapFactory[K], keys []K) {
+	table := factory.new(len(keys))
+	for _, key := range keys {
+		table.Set(key, 1)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		random := xorshift(1)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				table.Set(keys[random.next()%uint64(len(keys))], 2)
+			}
+		}
+	}()
+
+	ranger := table.(mapRanger[K])
+	visited := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ranger.Range(func(key K, value int64) bool {
+			mapRangeSink += value
+			visited++
+			return true
+		})
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(visited), "ns/entry")
+	b.StopTimer()
+
+	close(stop)
+	<-done
+}
+
+func BenchmarkMaps_Range_Full(b *testing.B) {
+	for _, size := range []int{10000, 1000000} {
+		keys := createIntKeys(size)
+		b.Run("Size="+strconv.Itoa(size), func(b *testing.B) {
+			for _, factory := range rangingMaps(mapFactories[int64]()) {
+				b.Run(factory.name, func(b *testing.B) {
+					flagNonconforming(b, "Int64", factory, keys)
+					benchmarkMapRange(b, factory, keys, size)
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkMaps_Range_EarlyExit10(b *testing.B) {
+	keys := createIntKeys(10000)
+	for _, factory := range rangingMaps(mapFactories[int64]()) {
+		b.Run(factory.name, func(b *testing.B) {
+			flagNonconforming(b, "Int64", factory, keys)
+			benchmarkMapRange(b, factory, keys, 10)
+		})
+	}
+}
+
+func BenchmarkMaps_Range_Mutating(b *testing.B) {
+	keys := createIntKeys(10000)
+	for _, factory := range concurrentMaps(rangingMaps(mapFactories[int64]())) {
+		b.Run(factory.name, func(b *testing.B) {
+			flagNonconforming(b, "Int64", factory, keys)
+			benchmarkMapRangeMutating(b, factory, keys)
+		})
+	}
+}
+
+func BenchmarkMaps_Range_Intintmap_Keys(b *testing.B) {
+	table := intintmap.New(10000, 0.6)
+	var k int64
+	for k = 0; k < 10000; k++ {
+		table.Put(k, 1)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for key := range table.Keys() {
+			value, _ := table.Get(key)
+			mapRangeSink += value
+		}
+	}
+	reportPerEntry(b, 10000)
+}
+
+// benchmarkMapFillLatency fills a table from the implementation's default
+// size timing every Set on its own, so that grow and rehash spikes show up in
+// the tail instead of being hidden in the average.
+func benchmarkMapFillLatency[K comparable](b *testing.B, factory mapFactory[K], keys []K) {
+	latencies := histogram.New()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table := factory.new(0)
+		for _, key := range keys {
+			started := time.Now()
+			table.Set(key, 1)
+			latencies.Record(int64(time.Since(started)))
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(latencies.Percentile(50)), "p50-ns")
+	b.ReportMetric(float64(latencies.Percentile(99)), "p99-ns")
+	b.ReportMetric(float64(latencies.Max()), "max-ns")
+}
+
+func BenchmarkMaps_FillLatency10K(b *testing.B) {
+	benchmarkMapKeyType(b, "Int64", mapFactories[int64](), createIntKeys(10000), benchmarkMapFillLatency[int64])
+	benchmarkMapKeyType(b, "String_Short", mapFactories[string](), createStringKeys(10000), benchmarkMapFillLatency[string])
+}
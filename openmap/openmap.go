@@ -0,0 +1,131 @@
+// Package openmap implements a generic open-addressing hash map with linear
+// probing and backward-shift deletion. It is not safe for concurrent use.
+package openmap
+
+import "hash/maphash"
+
+// maxLoad is the load factor, in percents, at which the table grows.
+const maxLoad = 75
+
+type Map[K comparable, V any] struct {
+	seed  maphash.Seed
+	mask  uint64
+	size  int
+	slots []slot[K, V]
+}
+
+type slot[K comparable, V any] struct {
+	key   K
+	value V
+	used  bool
+}
+
+func New[K comparable, V any](size int) *Map[K, V] {
+	capacity := 8
+	for capacity*maxLoad/100 < size {
+		capacity *= 2
+	}
+
+	return &Map[K, V]{
+		seed:  maphash.MakeSeed(),
+		mask:  uint64(capacity - 1),
+		slots: make([]slot[K, V], capacity),
+	}
+}
+
+func (m *Map[K, V]) home(key K) uint64 {
+	return maphash.Comparable(m.seed, key) & m.mask
+}
+
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	for i := m.home(key); ; i = (i + 1) & m.mask {
+		slot := &m.slots[i]
+		if !slot.used {
+			var zero V
+			return zero, false
+		}
+		if slot.key == key {
+			return slot.value, true
+		}
+	}
+}
+
+func (m *Map[K, V]) Set(key K, value V) {
+	for i := m.home(key); ; i = (i + 1) & m.mask {
+		slot := &m.slots[i]
+		if !slot.used {
+			break
+		}
+		if slot.key == key {
+			slot.value = value
+			return
+		}
+	}
+
+	if (m.size+1)*100 > len(m.slots)*maxLoad {
+		m.grow()
+	}
+
+	m.insert(key, value)
+	m.size++
+}
+
+// insert puts a key which is known to be absent into the first free slot.
+func (m *Map[K, V]) insert(key K, value V) {
+	i := m.home(key)
+	for m.slots[i].used {
+		i = (i + 1) & m.mask
+	}
+	m.slots[i] = slot[K, V]{key: key, value: value, used: true}
+}
+
+func (m *Map[K, V]) grow() {
+	slots := m.slots
+	m.slots = make([]slot[K, V], len(slots)*2)
+	m.mask = uint64(len(m.slots) - 1)
+	for i := range slots {
+		if slots[i].used {
+			m.insert(slots[i].key, slots[i].value)
+		}
+	}
+}
+
+func (m *Map[K, V]) Delete(key K) {
+	i := m.home(key)
+	for {
+		slot := &m.slots[i]
+		if !slot.used {
+			return
+		}
+		if slot.key == key {
+			break
+		}
+		i = (i + 1) & m.mask
+	}
+
+	// shift back the following entries of the cluster which would become
+	// unreachable because of the hole
+	for j := (i + 1) & m.mask; m.slots[j].used; j = (j + 1) & m.mask {
+		home := m.home(m.slots[j].key)
+		if (j-home)&m.mask >= (j-i)&m.mask {
+			m.slots[i] = m.slots[j]
+			i = j
+		}
+	}
+
+	m.slots[i] = slot[K, V]{}
+	m.size--
+}
+
+func (m *Map[K, V]) Len() int {
+	return m.size
+}
+
+// Range calls fn for every entry until it returns false.
+func (m *Map[K, V]) Range(fn func(key K, value V) bool) {
+	for i := range m.slots {
+		if m.slots[i].used && !fn(m.slots[i].key, m.slots[i].value) {
+			return
+		}
+	}
+}
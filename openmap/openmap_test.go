@@ -0,0 +1,107 @@
+package openmap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkModel compares every entry of the map with the model.
+func checkModel(t *testing.T, table *Map[int, int], model map[int]int) {
+	t.Helper()
+
+	used := 0
+	for i := range table.slots {
+		if table.slots[i].used {
+			used++
+		}
+	}
+	if used != table.Len() {
+		t.Fatalf("%d slots are used by %d entries", used, table.Len())
+	}
+
+	if table.Len() != len(model) {
+		t.Fatalf("Len() = %d, expected %d", table.Len(), len(model))
+	}
+
+	ranged := 0
+	table.Range(func(key, value int) bool {
+		ranged++
+		if expected, ok := model[key]; !ok || value != expected {
+			t.Fatalf("Range yields %d: %d, expected %d, %t", key, value, expected, ok)
+		}
+		return true
+	})
+	if ranged != len(model) {
+		t.Fatalf("Range yields %d entries, expected %d", ranged, len(model))
+	}
+
+	for key, expected := range model {
+		value, ok := table.Get(key)
+		if !ok || value != expected {
+			t.Fatalf("Get(%d) = %d, %t; expected %d, true", key, value, ok, expected)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	table := New[int, int](0)
+	model := map[int]int{}
+
+	for op := 0; op < 200000; op++ {
+		key := random.Intn(1000)
+		switch random.Intn(4) {
+		case 0, 1:
+			table.Set(key, op)
+			model[key] = op
+		case 2:
+			table.Delete(key)
+			delete(model, key)
+		case 3:
+			value, ok := table.Get(key)
+			expected, expectedOk := model[key]
+			if value != expected || ok != expectedOk {
+				t.Fatalf(
+					"op %d: Get(%d) = %d, %t; expected %d, %t",
+					op, key, value, ok, expected, expectedOk,
+				)
+			}
+		}
+
+		if op%10000 == 0 {
+			checkModel(t, table, model)
+		}
+	}
+
+	checkModel(t, table, model)
+}
+
+// TestMap_Full keeps the table right below its load factor, so clusters are
+// long and every delete has entries to shift back into the hole.
+func TestMap_Full(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	table := New[int, int](48)
+	slots := len(table.slots)
+	model := map[int]int{}
+
+	for op := 0; op < 100000; op++ {
+		key := random.Intn(1000)
+		if len(model) < 48 {
+			table.Set(key, op)
+			model[key] = op
+		} else {
+			table.Delete(key)
+			delete(model, key)
+		}
+
+		if op%1000 == 0 {
+			checkModel(t, table, model)
+		}
+	}
+
+	if len(table.slots) != slots {
+		t.Errorf("table grew from %d to %d slots", slots, len(table.slots))
+	}
+
+	checkModel(t, table, model)
+}
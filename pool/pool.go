@@ -0,0 +1,129 @@
+// Package pool implements several strategies of running a batch of tasks
+// concurrently, so that they can be compared on the same workloads.
+package pool
+
+import (
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+type Pool interface {
+	// Run executes all tasks and returns when every one of them is done.
+	Run(tasks []func())
+	// Close stops the workers, the pool must not be used afterwards.
+	Close()
+}
+
+// Spawn starts a goroutine per task.
+type Spawn struct{}
+
+func NewSpawn() *Spawn {
+	return &Spawn{}
+}
+
+func (pool *Spawn) Run(tasks []func()) {
+	wg := &sync.WaitGroup{}
+	wg.Add(len(tasks))
+	for _, task := range tasks {
+		go func() {
+			task()
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
+func (pool *Spawn) Close() {}
+
+// Errgroup starts a goroutine per task through errgroup, which does not let
+// more than limit of them run at once.
+type Errgroup struct {
+	limit int
+}
+
+func NewErrgroup(limit int) *Errgroup {
+	return &Errgroup{limit: limit}
+}
+
+func (pool *Errgroup) Run(tasks []func()) {
+	group := &errgroup.Group{}
+	group.SetLimit(pool.limit)
+	for _, task := range tasks {
+		group.Go(func() error {
+			task()
+			return nil
+		})
+	}
+	group.Wait()
+}
+
+func (pool *Errgroup) Close() {}
+
+// Shared runs a fixed number of workers which receive tasks from a single
+// channel.
+type Shared struct {
+	tasks chan func()
+	wg    sync.WaitGroup
+}
+
+func NewShared(workers int) *Shared {
+	pool := &Shared{tasks: make(chan func())}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for task := range pool.tasks {
+				task()
+				pool.wg.Done()
+			}
+		}()
+	}
+	return pool
+}
+
+func (pool *Shared) Run(tasks []func()) {
+	pool.wg.Add(len(tasks))
+	for _, task := range tasks {
+		pool.tasks <- task
+	}
+	pool.wg.Wait()
+}
+
+func (pool *Shared) Close() {
+	close(pool.tasks)
+}
+
+// RoundRobin runs a fixed number of workers, each one with its own channel,
+// and hands tasks out to them in turn.
+type RoundRobin struct {
+	workers []chan func()
+	wg      sync.WaitGroup
+}
+
+func NewRoundRobin(workers int) *RoundRobin {
+	pool := &RoundRobin{workers: make([]chan func(), workers)}
+	for i := range pool.workers {
+		tasks := make(chan func(), 64)
+		pool.workers[i] = tasks
+		go func() {
+			for task := range tasks {
+				task()
+				pool.wg.Done()
+			}
+		}()
+	}
+	return pool
+}
+
+func (pool *RoundRobin) Run(tasks []func()) {
+	pool.wg.Add(len(tasks))
+	for i, task := range tasks {
+		pool.workers[i%len(pool.workers)] <- task
+	}
+	pool.wg.Wait()
+}
+
+func (pool *RoundRobin) Close() {
+	for _, tasks := range pool.workers {
+		close(tasks)
+	}
+}
@@ -0,0 +1,110 @@
+package pool
+
+import "sync"
+
+// Stealing runs a fixed number of workers, each one with its own deque. A
+// worker takes tasks from the bottom of its deque and, once it is empty,
+// steals from the top of the others' ones.
+type Stealing struct {
+	deques []*deque
+	wake   []chan struct{}
+	wg     sync.WaitGroup
+}
+
+type deque struct {
+	mutex sync.Mutex
+	tasks []func()
+}
+
+func (deque *deque) push(task func()) {
+	deque.mutex.Lock()
+	deque.tasks = append(deque.tasks, task)
+	deque.mutex.Unlock()
+}
+
+func (deque *deque) popBottom() func() {
+	deque.mutex.Lock()
+	defer deque.mutex.Unlock()
+	if len(deque.tasks) == 0 {
+		return nil
+	}
+	task := deque.tasks[len(deque.tasks)-1]
+	deque.tasks = deque.tasks[:len(deque.tasks)-1]
+	return task
+}
+
+func (deque *deque) popTop() func() {
+	deque.mutex.Lock()
+	defer deque.mutex.Unlock()
+	if len(deque.tasks) == 0 {
+		return nil
+	}
+	task := deque.tasks[0]
+	deque.tasks = deque.tasks[1:]
+	return task
+}
+
+func NewStealing(workers int) *Stealing {
+	pool := &Stealing{
+		deques: make([]*deque, workers),
+		wake:   make([]chan struct{}, workers),
+	}
+	for i := range pool.deques {
+		pool.deques[i] = &deque{}
+		pool.wake[i] = make(chan struct{}, 1)
+	}
+	for i := range pool.deques {
+		go pool.work(i)
+	}
+	return pool
+}
+
+func (pool *Stealing) work(worker int) {
+	for range pool.wake[worker] {
+		for {
+			task := pool.deques[worker].popBottom()
+			if task == nil {
+				task = pool.steal(worker)
+			}
+
+			// tasks do not spawn tasks, so once every deque is empty
+			// there is nothing left until the next Run
+			if task == nil {
+				break
+			}
+
+			task()
+			pool.wg.Done()
+		}
+	}
+}
+
+func (pool *Stealing) steal(worker int) func() {
+	for i := 1; i < len(pool.deques); i++ {
+		task := pool.deques[(worker+i)%len(pool.deques)].popTop()
+		if task != nil {
+			return task
+		}
+	}
+	return nil
+}
+
+func (pool *Stealing) Run(tasks []func()) {
+	pool.wg.Add(len(tasks))
+	for i, task := range tasks {
+		pool.deques[i%len(pool.deques)].push(task)
+	}
+	for _, wake := range pool.wake {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	pool.wg.Wait()
+}
+
+func (pool *Stealing) Close() {
+	for _, wake := range pool.wake {
+		close(wake)
+	}
+}
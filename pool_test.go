@@ -0,0 +1,88 @@
+package benchmarks
+
+import (
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kovetskiy/benchmarks-go/pool"
+	"github.com/kovetskiy/benchmarks-go/workload"
+)
+
+type poolFactory struct {
+	name string
+	new  func(workers int) pool.Pool
+}
+
+var poolFactories = []poolFactory{
+	{"Spawn", func(int) pool.Pool { return pool.NewSpawn() }},
+	{"Shared", func(workers int) pool.Pool { return pool.NewShared(workers) }},
+	{"RoundRobin", func(workers int) pool.Pool { return pool.NewRoundRobin(workers) }},
+	{"Stealing", func(workers int) pool.Pool { return pool.NewStealing(workers) }},
+	{"Errgroup", func(workers int) pool.Pool { return pool.NewErrgroup(workers) }},
+}
+
+func TestPools(t *testing.T) {
+	for _, factory := range poolFactories {
+		workers := factory.new(4)
+		for _, count := range []int{0, 1, 3, 1000} {
+			var done atomic.Int64
+			tasks := make([]func(), count)
+			for i := range tasks {
+				tasks[i] = func() {
+					done.Add(1)
+				}
+			}
+
+			workers.Run(tasks)
+			if done.Load() != int64(count) {
+				t.Errorf("%s: %d of %d tasks are done", factory.name, done.Load(), count)
+			}
+		}
+		workers.Close()
+	}
+}
+
+// poolResult is padded to a cache line.
+type poolResult struct {
+	value uint64
+	_     [56]byte
+}
+
+func BenchmarkWorker_Pool(b *testing.B) {
+	for _, factory := range poolFactories {
+		b.Run(factory.name, func(b *testing.B) {
+			for _, job := range workloadJobs {
+				for _, count := range []int{100, 10000} {
+					b.Run("Job="+job.name+"/Tasks="+strconv.Itoa(count), func(b *testing.B) {
+						job := cpuJob(job.name, job.kind, job.cost)
+
+						// every task keeps its result in a slot of its own,
+						// so the tasks do not contend on the results
+						results := make([]poolResult, count)
+						tasks := make([]func(), count)
+						for i := range tasks {
+							tasks[i] = func() {
+								results[i].value += job.Run(uint64(i))
+							}
+						}
+
+						workers := factory.new(runtime.GOMAXPROCS(0))
+						defer workers.Close()
+
+						b.ResetTimer()
+						for i := 0; i < b.N; i++ {
+							workers.Run(tasks)
+						}
+						reportPerEntry(b, count)
+
+						for i := range results {
+							workload.Sink.Add(results[i].value)
+						}
+					})
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package queue
+
+import "sync"
+
+// Locked is a bounded queue over a circular slice guarded by a mutex, with
+// blocked producers and consumers parked on condition variables.
+type Locked[T any] struct {
+	mutex    sync.Mutex
+	notEmpty sync.Cond
+	notFull  sync.Cond
+
+	items []T
+	head  int
+	size  int
+}
+
+func NewLocked[T any](capacity int) *Locked[T] {
+	queue := &Locked[T]{items: make([]T, capacity)}
+	queue.notEmpty.L = &queue.mutex
+	queue.notFull.L = &queue.mutex
+	return queue
+}
+
+func (queue *Locked[T]) Push(value T) {
+	queue.mutex.Lock()
+	for queue.size == len(queue.items) {
+		queue.notFull.Wait()
+	}
+
+	queue.items[(queue.head+queue.size)%len(queue.items)] = value
+	queue.size++
+	queue.mutex.Unlock()
+	queue.notEmpty.Signal()
+}
+
+func (queue *Locked[T]) Pop() T {
+	queue.mutex.Lock()
+	for queue.size == 0 {
+		queue.notEmpty.Wait()
+	}
+
+	value := queue.items[queue.head]
+	queue.items[queue.head] = *new(T)
+	queue.head = (queue.head + 1) % len(queue.items)
+	queue.size--
+	queue.mutex.Unlock()
+	queue.notFull.Signal()
+	return value
+}
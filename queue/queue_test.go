@@ -0,0 +1,121 @@
+package queue
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	ring := NewRing[int](5)
+	if len(ring.cells) != 8 {
+		t.Fatalf("capacity 5 gives %d cells, expected 8", len(ring.cells))
+	}
+
+	model := []int{}
+	for op := 0; op < 100000; op++ {
+		if random.Intn(2) == 0 {
+			ok := ring.TryPush(op)
+			if ok != (len(model) < 8) {
+				t.Fatalf("op %d: TryPush = %t with %d values queued", op, ok, len(model))
+			}
+			if ok {
+				model = append(model, op)
+			}
+		} else {
+			value, ok := ring.TryPop()
+			if ok != (len(model) > 0) {
+				t.Fatalf("op %d: TryPop = %t with %d values queued", op, ok, len(model))
+			}
+			if ok {
+				if value != model[0] {
+					t.Fatalf("op %d: TryPop = %d, expected %d", op, value, model[0])
+				}
+				model = model[1:]
+			}
+		}
+	}
+}
+
+func TestLocked(t *testing.T) {
+	queue := NewLocked[int](3)
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 3; i++ {
+			queue.Push(round*3 + i)
+		}
+		for i := 0; i < 3; i++ {
+			value := queue.Pop()
+			if value != round*3+i {
+				t.Fatalf("round %d: Pop = %d, expected %d", round, value, round*3+i)
+			}
+		}
+	}
+}
+
+// TestQueues_Concurrent checks that every value is received exactly once
+// and that every consumer receives the values of a producer in the order
+// they were pushed.
+func TestQueues_Concurrent(t *testing.T) {
+	const (
+		producers = 4
+		consumers = 4
+		items     = 100000
+	)
+
+	type queue interface {
+		Push(value int)
+		Pop() int
+	}
+
+	for name, queue := range map[string]queue{
+		"Ring":   NewRing[int](64),
+		"Locked": NewLocked[int](64),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for producer := 0; producer < producers; producer++ {
+				go func() {
+					for value := producer; value < items; value += producers {
+						queue.Push(value)
+					}
+				}()
+			}
+
+			received := make([][]int, consumers)
+			wg := &sync.WaitGroup{}
+			for consumer := 0; consumer < consumers; consumer++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for k := 0; k < items/consumers; k++ {
+						received[consumer] = append(received[consumer], queue.Pop())
+					}
+				}()
+			}
+			wg.Wait()
+
+			seen := make([]bool, items)
+			for consumer, values := range received {
+				last := make([]int, producers)
+				for i := range last {
+					last[i] = -1
+				}
+
+				for _, value := range values {
+					if seen[value] {
+						t.Fatalf("value %d is received twice", value)
+					}
+					seen[value] = true
+
+					if value <= last[value%producers] {
+						t.Fatalf(
+							"consumer %d receives %d after %d from the same producer",
+							consumer, value, last[value%producers],
+						)
+					}
+					last[value%producers] = value
+				}
+			}
+		})
+	}
+}
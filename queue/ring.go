@@ -0,0 +1,111 @@
+// Package queue implements bounded multi-producer multi-consumer queues to
+// compare with channels.
+package queue
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// cacheLine pads the hot counters apart so producers and consumers do not
+// invalidate each other's cache lines.
+type cacheLine [64]byte
+
+// Ring is a lock-free bounded queue after Dmitry Vyukov's MPMC design: every
+// cell carries a sequence number which tells producers and consumers whose
+// turn it is, so each side only has to win a CAS on its own position.
+type Ring[T any] struct {
+	_    cacheLine
+	head atomic.Uint64
+	_    cacheLine
+	tail atomic.Uint64
+	_    cacheLine
+
+	mask  uint64
+	cells []cell[T]
+}
+
+type cell[T any] struct {
+	sequence atomic.Uint64
+	value    T
+}
+
+// NewRing rounds the capacity up to a power of two.
+func NewRing[T any](capacity int) *Ring[T] {
+	size := 1
+	for size < capacity {
+		size *= 2
+	}
+
+	ring := &Ring[T]{
+		mask:  uint64(size - 1),
+		cells: make([]cell[T], size),
+	}
+	for i := range ring.cells {
+		ring.cells[i].sequence.Store(uint64(i))
+	}
+
+	return ring
+}
+
+func (ring *Ring[T]) TryPush(value T) bool {
+	position := ring.tail.Load()
+	for {
+		cell := &ring.cells[position&ring.mask]
+		diff := int64(cell.sequence.Load() - position)
+		switch {
+		case diff == 0:
+			if ring.tail.CompareAndSwap(position, position+1) {
+				cell.value = value
+				cell.sequence.Store(position + 1)
+				return true
+			}
+			position = ring.tail.Load()
+		case diff < 0:
+			return false
+		default:
+			position = ring.tail.Load()
+		}
+	}
+}
+
+func (ring *Ring[T]) TryPop() (T, bool) {
+	position := ring.head.Load()
+	for {
+		cell := &ring.cells[position&ring.mask]
+		diff := int64(cell.sequence.Load() - (position + 1))
+		switch {
+		case diff == 0:
+			if ring.head.CompareAndSwap(position, position+1) {
+				value := cell.value
+				cell.value = *new(T)
+				cell.sequence.Store(position + ring.mask + 1)
+				return value, true
+			}
+			position = ring.head.Load()
+		case diff < 0:
+			var zero T
+			return zero, false
+		default:
+			position = ring.head.Load()
+		}
+	}
+}
+
+// Push spins, yielding the processor, until there is room for the value.
+func (ring *Ring[T]) Push(value T) {
+	for !ring.TryPush(value) {
+		runtime.Gosched()
+	}
+}
+
+// Pop spins, yielding the processor, until there is a value.
+func (ring *Ring[T]) Pop() T {
+	for {
+		value, ok := ring.TryPop()
+		if ok {
+			return value
+		}
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,44 @@
+package benchmarks
+
+import "github.com/kovetskiy/benchmarks-go/queue"
+
+// Queue is the common surface of the queues under benchmark, both calls block
+// until they can proceed.
+type Queue interface {
+	Push(value int64)
+	Pop() int64
+}
+
+type queueFactory struct {
+	name string
+	new  func() Queue
+}
+
+func queueFactories() []queueFactory {
+	return []queueFactory{
+		{name: "Chan_Unbuffered", new: newChanQueue(0)},
+		{name: "Chan_1", new: newChanQueue(1)},
+		{name: "Chan_64", new: newChanQueue(64)},
+		{name: "Chan_1024", new: newChanQueue(1024)},
+		{name: "Ring_64", new: func() Queue { return queue.NewRing[int64](64) }},
+		{name: "Ring_1024", new: func() Queue { return queue.NewRing[int64](1024) }},
+		{name: "Locked_64", new: func() Queue { return queue.NewLocked[int64](64) }},
+		{name: "Locked_1024", new: func() Queue { return queue.NewLocked[int64](1024) }},
+	}
+}
+
+type chanQueue chan int64
+
+func newChanQueue(capacity int) func() Queue {
+	return func() Queue {
+		return chanQueue(make(chan int64, capacity))
+	}
+}
+
+func (queue chanQueue) Push(value int64) {
+	queue <- value
+}
+
+func (queue chanQueue) Pop() int64 {
+	return <-queue
+}
@@ -0,0 +1,97 @@
+package benchmarks
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kovetskiy/benchmarks-go/workload"
+)
+
+var queueTopologies = []struct {
+	producers int
+	consumers int
+}{
+	{1, 1},
+	{1, 4},
+	{4, 1},
+	{4, 4},
+	{8, 8},
+}
+
+// passQueue sends items values through the queue from the producers to the
+// consumers, which run the job for every value they receive, and returns the
+// sum of the job results. An empty job returns the value itself.
+func passQueue(queue Queue, job workload.Job, items, producers, consumers int) int64 {
+	var sum atomic.Int64
+	wg := &sync.WaitGroup{}
+
+	for producer := 0; producer < producers; producer++ {
+		go func(producer int) {
+			for k := producer; k < items; k += producers {
+				queue.Push(int64(k))
+			}
+		}(producer)
+	}
+
+	for consumer := 0; consumer < consumers; consumer++ {
+		count := items / consumers
+		if consumer < items%consumers {
+			count++
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local int64
+			for k := 0; k < count; k++ {
+				local += int64(job.Run(uint64(queue.Pop())))
+			}
+			sum.Add(local)
+		}()
+	}
+
+	wg.Wait()
+	return sum.Load()
+}
+
+func TestQueues(t *testing.T) {
+	const items = 100000
+	for _, factory := range queueFactories() {
+		for _, topology := range queueTopologies {
+			sum := passQueue(factory.new(), workload.Job{}, items, topology.producers, topology.consumers)
+			if sum != items*(items-1)/2 {
+				t.Errorf(
+					"%s %d:%d: received sum %d, expected %d",
+					factory.name, topology.producers, topology.consumers,
+					sum, items*(items-1)/2,
+				)
+			}
+		}
+	}
+}
+
+func BenchmarkQueue_Topology(b *testing.B) {
+	for _, factory := range queueFactories() {
+		b.Run(factory.name, func(b *testing.B) {
+			for _, job := range passJobs {
+				for _, topology := range queueTopologies {
+					name := "Job=" + job.name +
+						"/Producers=" + strconv.Itoa(topology.producers) +
+						"/Consumers=" + strconv.Itoa(topology.consumers)
+					b.Run(name, func(b *testing.B) {
+						job := cpuJob(job.name, job.kind, job.cost)
+						result := int64(0)
+						b.ResetTimer()
+						for i := 0; i < b.N; i++ {
+							result += passQueue(factory.new(), job, 10000, topology.producers, topology.consumers)
+						}
+						reportPerEntry(b, 10000)
+						workload.Sink.Add(uint64(result))
+					})
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package shardmap implements a concurrent map which is split into a number
+// of shards, each one is a std map protected by its own lock.
+package shardmap
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"sync"
+)
+
+const DefaultShards = 32
+
+type Config[K comparable] struct {
+	// Shards is rounded up to a power of two, DefaultShards is used if zero.
+	Shards int
+	// Hash picks the shard by the high bits of its result, maphash with a
+	// random seed is used if nil.
+	Hash func(key K) uint64
+}
+
+type Map[K comparable, V any] struct {
+	hash   func(key K) uint64
+	shift  uint
+	shards []shard[K, V]
+}
+
+type shard[K comparable, V any] struct {
+	sync.RWMutex
+	table map[K]V
+
+	// pads the shard to a cache line so neighbouring locks do not share it
+	_ [32]byte
+}
+
+func New[K comparable, V any](size int) *Map[K, V] {
+	return NewWithConfig[K, V](size, Config[K]{})
+}
+
+func NewWithConfig[K comparable, V any](size int, config Config[K]) *Map[K, V] {
+	if config.Shards <= 0 {
+		config.Shards = DefaultShards
+	}
+
+	if config.Hash == nil {
+		seed := maphash.MakeSeed()
+		config.Hash = func(key K) uint64 {
+			return maphash.Comparable(seed, key)
+		}
+	}
+
+	power := bits.Len(uint(config.Shards - 1))
+
+	shards := make([]shard[K, V], 1<<power)
+	for i := range shards {
+		shards[i].table = make(map[K]V, size/len(shards))
+	}
+
+	return &Map[K, V]{
+		hash:   config.Hash,
+		shift:  uint(64 - power),
+		shards: shards,
+	}
+}
+
+func (m *Map[K, V]) shard(key K) *shard[K, V] {
+	return &m.shards[m.hash(key)>>m.shift]
+}
+
+func (m *Map[K, V]) Shards() int {
+	return len(m.shards)
+}
+
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	shard := m.shard(key)
+	shard.RLock()
+	value, ok := shard.table[key]
+	shard.RUnlock()
+	return value, ok
+}
+
+func (m *Map[K, V]) Set(key K, value V) {
+	shard := m.shard(key)
+	shard.Lock()
+	shard.table[key] = value
+	shard.Unlock()
+}
+
+func (m *Map[K, V]) Delete(key K) {
+	shard := m.shard(key)
+	shard.Lock()
+	delete(shard.table, key)
+	shard.Unlock()
+}
+
+func (m *Map[K, V]) Len() int {
+	size := 0
+	for i := range m.shards {
+		m.shards[i].RLock()
+		size += len(m.shards[i].table)
+		m.shards[i].RUnlock()
+	}
+	return size
+}
+
+// Range calls fn for every entry until it returns false. Shards are locked
+// one at a time, so it is not a consistent snapshot of the whole map.
+func (m *Map[K, V]) Range(fn func(key K, value V) bool) {
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.RLock()
+		for key, value := range shard.table {
+			if !fn(key, value) {
+				shard.RUnlock()
+				return
+			}
+		}
+		shard.RUnlock()
+	}
+}
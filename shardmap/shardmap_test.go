@@ -0,0 +1,121 @@
+package shardmap
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// checkModel compares every entry of the map with the model.
+func checkModel(t *testing.T, table *Map[int, int], model map[int]int) {
+	t.Helper()
+
+	if table.Len() != len(model) {
+		t.Fatalf("Len() = %d, expected %d", table.Len(), len(model))
+	}
+
+	ranged := 0
+	table.Range(func(key, value int) bool {
+		ranged++
+		if expected, ok := model[key]; !ok || value != expected {
+			t.Fatalf("Range yields %d: %d, expected %d, %t", key, value, expected, ok)
+		}
+		return true
+	})
+	if ranged != len(model) {
+		t.Fatalf("Range yields %d entries, expected %d", ranged, len(model))
+	}
+
+	for key, expected := range model {
+		value, ok := table.Get(key)
+		if !ok || value != expected {
+			t.Fatalf("Get(%d) = %d, %t; expected %d, true", key, value, ok, expected)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	table := NewWithConfig[int, int](0, Config[int]{Shards: 4})
+	model := map[int]int{}
+
+	for op := 0; op < 200000; op++ {
+		key := random.Intn(1000)
+		switch random.Intn(4) {
+		case 0, 1:
+			table.Set(key, op)
+			model[key] = op
+		case 2:
+			table.Delete(key)
+			delete(model, key)
+		case 3:
+			value, ok := table.Get(key)
+			expected, expectedOk := model[key]
+			if value != expected || ok != expectedOk {
+				t.Fatalf(
+					"op %d: Get(%d) = %d, %t; expected %d, %t",
+					op, key, value, ok, expected, expectedOk,
+				)
+			}
+		}
+
+		if op%10000 == 0 {
+			checkModel(t, table, model)
+		}
+	}
+
+	checkModel(t, table, model)
+}
+
+func TestMap_Shards(t *testing.T) {
+	for _, test := range []struct {
+		shards   int
+		expected int
+	}{
+		{0, DefaultShards},
+		{1, 1},
+		{3, 4},
+		{64, 64},
+	} {
+		table := NewWithConfig[int, int](0, Config[int]{Shards: test.shards})
+		if table.Shards() != test.expected {
+			t.Errorf("Shards: %d gives %d shards, expected %d", test.shards, table.Shards(), test.expected)
+		}
+	}
+}
+
+// TestMap_Concurrent has every goroutine work on keys of its own, so the
+// final state is known, while all of them share the shards.
+func TestMap_Concurrent(t *testing.T) {
+	const (
+		workers = 8
+		keys    = 1000
+	)
+
+	table := New[int, int](0)
+	wg := &sync.WaitGroup{}
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < 10; round++ {
+				for key := worker; key < keys; key += workers {
+					table.Set(key, round)
+					if round%2 == 1 && key%3 == 0 {
+						table.Delete(key)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	model := map[int]int{}
+	for key := 0; key < keys; key++ {
+		if key%3 != 0 {
+			model[key] = 9
+		}
+	}
+
+	checkModel(t, table, model)
+}
@@ -0,0 +1,223 @@
+// Package swissmap implements a generic Swiss table: an open-addressing hash
+// map which keeps one control byte per slot and probes groups of 8 slots at
+// once, comparing all control bytes of a group in a single word with SWAR bit
+// tricks instead of SIMD instructions. It is not safe for concurrent use.
+package swissmap
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+const (
+	groupSize = 8
+
+	// control bytes; a full slot keeps 7 low bits of the key hash
+	empty   = 0x80
+	deleted = 0xfe
+
+	lsb = 0x0101010101010101
+	msb = 0x8080808080808080
+
+	// maxLoad is the share of slots, in eighths, filled before the table grows
+	maxLoad = 7
+)
+
+type Map[K comparable, V any] struct {
+	seed   maphash.Seed
+	mask   uint64
+	size   int
+	free   int
+	ctrl   []uint64
+	groups []group[K, V]
+}
+
+type group[K comparable, V any] struct {
+	keys   [groupSize]K
+	values [groupSize]V
+}
+
+func New[K comparable, V any](size int) *Map[K, V] {
+	groups := 1
+	for groups*groupSize*maxLoad/8 < size {
+		groups *= 2
+	}
+
+	m := &Map[K, V]{seed: maphash.MakeSeed()}
+	m.reset(groups)
+	return m
+}
+
+func (m *Map[K, V]) reset(groups int) {
+	m.mask = uint64(groups - 1)
+	m.free = groups * groupSize * maxLoad / 8
+	m.ctrl = make([]uint64, groups)
+	m.groups = make([]group[K, V], groups)
+	for i := range m.ctrl {
+		m.ctrl[i] = lsb * empty
+	}
+}
+
+// matchByte returns a bit set with the high bit of every byte of ctrl equal
+// to b. A borrow may report a false positive right above a real match, so
+// every candidate has to be checked.
+func matchByte(ctrl uint64, b uint8) uint64 {
+	x := ctrl ^ (lsb * uint64(b))
+	return (x - lsb) &^ x & msb
+}
+
+func matchEmpty(ctrl uint64) uint64 {
+	// empty and deleted both have the high bit set, but only deleted has
+	// the second lowest one
+	return ctrl &^ (ctrl << 6) & msb
+}
+
+func matchEmptyOrDeleted(ctrl uint64) uint64 {
+	return ctrl & msb
+}
+
+func first(matches uint64) uint64 {
+	return uint64(bits.TrailingZeros64(matches) >> 3)
+}
+
+func (m *Map[K, V]) setCtrl(g, slot uint64, b uint8) {
+	shift := slot * 8
+	m.ctrl[g] = m.ctrl[g]&^(0xff<<shift) | uint64(b)<<shift
+}
+
+func (m *Map[K, V]) hash(key K) (uint64, uint8) {
+	hash := maphash.Comparable(m.seed, key)
+	return hash >> 7, uint8(hash & 0x7f)
+}
+
+// find returns the group and the slot of the key.
+func (m *Map[K, V]) find(key K, h1 uint64, h2 uint8) (uint64, uint64, bool) {
+	g := h1 & m.mask
+	for step := uint64(1); ; step++ {
+		ctrl := m.ctrl[g]
+		for matches := matchByte(ctrl, h2); matches != 0; matches &= matches - 1 {
+			slot := first(matches)
+			if uint8(ctrl>>(slot*8)) == h2 && m.groups[g].keys[slot] == key {
+				return g, slot, true
+			}
+		}
+
+		if matchEmpty(ctrl) != 0 {
+			return 0, 0, false
+		}
+
+		g = (g + step) & m.mask
+	}
+}
+
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	h1, h2 := m.hash(key)
+	g, slot, ok := m.find(key, h1, h2)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return m.groups[g].values[slot], true
+}
+
+func (m *Map[K, V]) Set(key K, value V) {
+	h1, h2 := m.hash(key)
+	g, slot, ok := m.find(key, h1, h2)
+	if ok {
+		m.groups[g].values[slot] = value
+		return
+	}
+
+	if m.free == 0 {
+		m.rehash()
+	}
+
+	m.insert(key, value, h1, h2)
+	m.size++
+}
+
+// insert puts a key which is known to be absent into the first empty or
+// deleted slot of its probe sequence.
+func (m *Map[K, V]) insert(key K, value V, h1 uint64, h2 uint8) {
+	g := h1 & m.mask
+	for step := uint64(1); ; step++ {
+		matches := matchEmptyOrDeleted(m.ctrl[g])
+		if matches != 0 {
+			slot := first(matches)
+			if matchEmpty(m.ctrl[g])&(0x80<<(slot*8)) != 0 {
+				m.free--
+			}
+
+			m.setCtrl(g, slot, h2)
+			m.groups[g].keys[slot] = key
+			m.groups[g].values[slot] = value
+			return
+		}
+
+		g = (g + step) & m.mask
+	}
+}
+
+// rehash grows the table, or only drops the deleted slots if there are
+// enough of them to make room without growing.
+func (m *Map[K, V]) rehash() {
+	groups := len(m.groups)
+	if m.size >= groups*groupSize*maxLoad/16 {
+		groups *= 2
+	}
+
+	ctrl, old := m.ctrl, m.groups
+	m.reset(groups)
+	for g := range old {
+		for slot := uint64(0); slot < groupSize; slot++ {
+			if ctrl[g]&(0x80<<(slot*8)) != 0 {
+				continue
+			}
+
+			key := old[g].keys[slot]
+			h1, h2 := m.hash(key)
+			m.insert(key, old[g].values[slot], h1, h2)
+		}
+	}
+}
+
+func (m *Map[K, V]) Delete(key K) {
+	h1, h2 := m.hash(key)
+	g, slot, ok := m.find(key, h1, h2)
+	if !ok {
+		return
+	}
+
+	// a probe stops at a group with an empty slot, so the slot may be
+	// emptied only if its group already has one, otherwise it has to stay
+	// as a tombstone to keep the following keys reachable
+	if matchEmpty(m.ctrl[g]) != 0 {
+		m.setCtrl(g, slot, empty)
+		m.free++
+	} else {
+		m.setCtrl(g, slot, deleted)
+	}
+
+	m.groups[g].keys[slot] = *new(K)
+	m.groups[g].values[slot] = *new(V)
+	m.size--
+}
+
+func (m *Map[K, V]) Len() int {
+	return m.size
+}
+
+// Range calls fn for every entry until it returns false.
+func (m *Map[K, V]) Range(fn func(key K, value V) bool) {
+	for g := range m.groups {
+		for slot := uint64(0); slot < groupSize; slot++ {
+			if m.ctrl[g]&(0x80<<(slot*8)) != 0 {
+				continue
+			}
+
+			if !fn(m.groups[g].keys[slot], m.groups[g].values[slot]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package swissmap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkModel compares every entry of the map with the model.
+func checkModel(t *testing.T, table *Map[int, int], model map[int]int) {
+	t.Helper()
+
+	if table.Len() != len(model) {
+		t.Fatalf("Len() = %d, expected %d", table.Len(), len(model))
+	}
+
+	ranged := 0
+	table.Range(func(key, value int) bool {
+		ranged++
+		if expected, ok := model[key]; !ok || value != expected {
+			t.Fatalf("Range yields %d: %d, expected %d, %t", key, value, expected, ok)
+		}
+		return true
+	})
+	if ranged != len(model) {
+		t.Fatalf("Range yields %d entries, expected %d", ranged, len(model))
+	}
+
+	for key, expected := range model {
+		value, ok := table.Get(key)
+		if !ok || value != expected {
+			t.Fatalf("Get(%d) = %d, %t; expected %d, true", key, value, ok, expected)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	table := New[int, int](0)
+	model := map[int]int{}
+
+	for op := 0; op < 200000; op++ {
+		key := random.Intn(1000)
+		switch random.Intn(4) {
+		case 0, 1:
+			table.Set(key, op)
+			model[key] = op
+		case 2:
+			table.Delete(key)
+			delete(model, key)
+		case 3:
+			value, ok := table.Get(key)
+			expected, expectedOk := model[key]
+			if value != expected || ok != expectedOk {
+				t.Fatalf(
+					"op %d: Get(%d) = %d, %t; expected %d, %t",
+					op, key, value, ok, expected, expectedOk,
+				)
+			}
+		}
+
+		if op%10000 == 0 {
+			checkModel(t, table, model)
+		}
+	}
+
+	checkModel(t, table, model)
+}
+
+// TestMap_Tombstones keeps replacing the oldest key with a new one, so full
+// groups fill up with tombstones which only a rehash in place can drop
+// without growing the table.
+func TestMap_Tombstones(t *testing.T) {
+	const live = 50
+
+	table := New[int, int](64)
+	groups := len(table.groups)
+	model := map[int]int{}
+
+	for key := 0; key < 100000; key++ {
+		table.Set(key, key)
+		model[key] = key
+		if key >= live {
+			table.Delete(key - live)
+			delete(model, key-live)
+		}
+	}
+
+	if len(table.groups) != groups {
+		t.Errorf("table grew from %d to %d groups with %d keys", groups, len(table.groups), live)
+	}
+
+	checkModel(t, table, model)
+
+	for key := 0; key < 100000-live; key++ {
+		if _, ok := table.Get(key); ok {
+			t.Fatalf("deleted key %d is found", key)
+		}
+	}
+}
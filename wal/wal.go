@@ -0,0 +1,375 @@
+// Package wal is a write-ahead log of ledger packets. Every packet is stored
+// as a frame of its length, the CRC32-C of the encoded packet and the packet
+// itself. The log is split into numbered segment files, a new one is started
+// once the current one would grow over the segment size.
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kovetskiy/benchmarks-go/ledger"
+)
+
+const (
+	headerSize = 8
+	flushSize  = 64 << 10
+
+	segmentSuffix = ".wal"
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	ErrCorrupt = errors.New("wal: corrupt segment")
+)
+
+// FrameSize is the length of a packet frame.
+var FrameSize = headerSize + ledger.PacketSize
+
+type Options struct {
+	// SegmentSize is the size after which a new segment is started.
+	SegmentSize int64
+
+	// SyncEvery makes Append sync the log every so many packets, zero
+	// leaves syncing to the caller.
+	SyncEvery int
+}
+
+type Log struct {
+	dir     string
+	options Options
+
+	file     *os.File
+	segment  int
+	size     int64
+	buffer   []byte
+	unsynced int
+}
+
+// Open opens the log in dir, creating dir if needed. A torn tail left in the
+// last segment by a crash is truncated and appending continues after the
+// last complete packet, a bad frame followed by a valid one is reported as
+// ErrCorrupt.
+func Open(dir string, options Options) (*Log, error) {
+	if options.SegmentSize <= 0 {
+		options.SegmentSize = 64 << 20
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &Log{
+		dir:     dir,
+		options: options,
+		buffer:  make([]byte, 0, flushSize+FrameSize),
+	}
+
+	if len(segments) == 0 {
+		return log, log.openSegment(0)
+	}
+
+	log.segment = segments[len(segments)-1]
+
+	log.size, err = recoverSegment(segmentPath(log.dir, log.segment))
+	if err != nil {
+		return nil, err
+	}
+
+	log.file, err = os.OpenFile(segmentPath(log.dir, log.segment), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+func segmentPath(dir string, segment int) string {
+	return filepath.Join(dir, fmt.Sprintf("%016d%s", segment, segmentSuffix))
+}
+
+func (log *Log) openSegment(segment int) error {
+	file, err := os.OpenFile(segmentPath(log.dir, segment), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	log.file = file
+	log.segment = segment
+	log.size = 0
+
+	return syncDir(log.dir)
+}
+
+// Append adds the packet to the log. It is buffered until the buffer fills
+// up, the segment is rotated or the log is synced.
+func (log *Log) Append(packet *ledger.Packet) error {
+	if log.size > 0 && log.size+int64(FrameSize) > log.options.SegmentSize {
+		err := log.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	log.buffer = appendFrame(log.buffer, packet)
+	log.size += int64(FrameSize)
+	log.unsynced++
+
+	if log.options.SyncEvery > 0 && log.unsynced >= log.options.SyncEvery {
+		return log.Sync()
+	}
+
+	if len(log.buffer) >= flushSize {
+		return log.flush()
+	}
+
+	return nil
+}
+
+func (log *Log) rotate() error {
+	err := log.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = log.file.Close()
+	if err != nil {
+		return err
+	}
+
+	return log.openSegment(log.segment + 1)
+}
+
+func (log *Log) flush() error {
+	if len(log.buffer) == 0 {
+		return nil
+	}
+
+	_, err := log.file.Write(log.buffer)
+	log.buffer = log.buffer[:0]
+	return err
+}
+
+// Sync writes out the buffered packets and syncs the current segment.
+func (log *Log) Sync() error {
+	err := log.flush()
+	if err != nil {
+		return err
+	}
+
+	log.unsynced = 0
+	return log.file.Sync()
+}
+
+func (log *Log) Close() error {
+	err := log.Sync()
+	if err != nil {
+		log.file.Close()
+		return err
+	}
+
+	return log.file.Close()
+}
+
+func appendFrame(buffer []byte, packet *ledger.Packet) []byte {
+	start := len(buffer)
+	buffer = append(buffer, make([]byte, headerSize)...)
+	buffer = ledger.AppendPacket(buffer, packet)
+
+	payload := buffer[start+headerSize:]
+	binary.BigEndian.PutUint32(buffer[start:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buffer[start+4:], crc32.Checksum(payload, crcTable))
+
+	return buffer
+}
+
+// nextFrame returns the payload of the frame at the start of data, or false
+// if the frame is incomplete or its checksum does not match.
+func nextFrame(data []byte) ([]byte, bool) {
+	if len(data) < headerSize {
+		return nil, false
+	}
+
+	length := binary.BigEndian.Uint32(data)
+	if length != uint32(ledger.PacketSize) || len(data)-headerSize < int(length) {
+		return nil, false
+	}
+
+	payload := data[headerSize : headerSize+int(length)]
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(data[4:]) {
+		return nil, false
+	}
+
+	return payload, true
+}
+
+// readSegment calls fn for every packet of the segment and returns the
+// length of the valid prefix, which ends at the first bad frame. A bad frame
+// followed by any valid one is not a torn tail but corruption in the middle
+// of the segment, it is reported as ErrCorrupt.
+func readSegment(path string, fn func(*ledger.Packet) error) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	offset := 0
+	packet := ledger.Packet{}
+	for {
+		payload, ok := nextFrame(data[offset:])
+		if !ok {
+			break
+		}
+
+		if fn != nil {
+			err := ledger.DecodePacket(payload, &packet)
+			if err != nil {
+				return int64(offset), err
+			}
+
+			err = fn(&packet)
+			if err != nil {
+				return int64(offset), err
+			}
+		}
+
+		offset += headerSize + len(payload)
+	}
+
+	// frames are of the same size, so any frame after the bad one is at a
+	// multiple of the frame size from it
+	for next := offset + FrameSize; next+FrameSize <= len(data); next += FrameSize {
+		_, ok := nextFrame(data[next:])
+		if ok {
+			return int64(offset), fmt.Errorf(
+				"%w: %s at offset %d is followed by a valid frame at offset %d",
+				ErrCorrupt, path, offset, next,
+			)
+		}
+	}
+
+	return int64(offset), nil
+}
+
+// recoverSegment truncates a torn tail of the segment after its last
+// complete frame.
+func recoverSegment(path string) (int64, error) {
+	valid, err := readSegment(path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return valid, truncate(path, valid)
+}
+
+func truncate(path string, size int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == size {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	err = file.Truncate(size)
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Replay calls fn for every packet in the log in the order they were
+// appended. A torn tail of the last segment is truncated, a bad frame in
+// any other segment or followed by a valid frame is reported as ErrCorrupt. The packet passed to fn is
+// reused between calls.
+func Replay(dir string, fn func(*ledger.Packet) error) error {
+	segments, err := listSegments(dir)
+	if err != nil {
+		return err
+	}
+
+	for index, segment := range segments {
+		path := segmentPath(dir, segment)
+
+		valid, err := readSegment(path, fn)
+		if err != nil {
+			return err
+		}
+
+		if index == len(segments)-1 {
+			return truncate(path, valid)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if info.Size() != valid {
+			return fmt.Errorf("%w: %s at offset %d", ErrCorrupt, path, valid)
+		}
+	}
+
+	return nil
+}
+
+func listSegments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []int{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		var segment int
+		_, err := fmt.Sscanf(strings.TrimSuffix(name, segmentSuffix), "%d", &segment)
+		if err != nil {
+			continue
+		}
+
+		segments = append(segments, segment)
+	}
+
+	sort.Ints(segments)
+
+	return segments, nil
+}
+
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
@@ -0,0 +1,205 @@
+package wal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/kovetskiy/benchmarks-go/ledger"
+)
+
+func appendPackets(log *Log, from, to int) error {
+	for id := from; id < to; id++ {
+		err := log.Append(&ledger.Packet{ID: int64(id), Amount: int64(id) * 10})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func replayIDs(dir string) ([]int64, error) {
+	ids := []int64{}
+	err := Replay(dir, func(packet *ledger.Packet) error {
+		ids = append(ids, packet.ID)
+		return nil
+	})
+
+	return ids, err
+}
+
+func TestWAL(t *testing.T) {
+	dir := t.TempDir()
+	options := Options{SegmentSize: int64(FrameSize) * 100}
+
+	log, err := Open(dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := appendPackets(log, 0, 1050); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.wal"))
+	if len(segments) != 11 {
+		t.Fatalf("%d segments, expected 11", len(segments))
+	}
+
+	// a crash in the middle of a frame leaves a torn tail
+	last := segments[len(segments)-1]
+	file, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write(make([]byte, FrameSize/2))
+	file.Close()
+
+	ids, err := replayIDs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1050 {
+		t.Fatalf("replayed %d packets, expected 1050", len(ids))
+	}
+	for index, id := range ids {
+		if id != int64(index) {
+			t.Fatalf("packet %d has id %d", index, id)
+		}
+	}
+
+	info, _ := os.Stat(last)
+	if info.Size() != int64(FrameSize)*50 {
+		t.Fatalf("torn tail is not truncated, segment size %d", info.Size())
+	}
+
+	log, err = Open(dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := appendPackets(log, 1050, 1100); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err = replayIDs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1100 || ids[1099] != 1099 {
+		t.Fatalf("replayed %d packets after reopening, expected 1100", len(ids))
+	}
+
+	// a bad frame followed by valid ones in the last segment is corruption,
+	// truncating it would drop acknowledged packets
+	segments, _ = filepath.Glob(filepath.Join(dir, "*.wal"))
+	last = segments[len(segments)-1]
+	before, _ := os.Stat(last)
+	file, err = os.OpenFile(last, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := []byte{0}
+	file.ReadAt(original, int64(FrameSize)*10+20)
+	file.WriteAt([]byte{^original[0]}, int64(FrameSize)*10+20)
+
+	_, err = replayIDs(dir)
+	if !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("corrupt last segment is replayed with error %v", err)
+	}
+	_, err = Open(dir, options)
+	if !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("corrupt last segment is opened with error %v", err)
+	}
+	info, _ = os.Stat(last)
+	if info.Size() != before.Size() {
+		t.Fatalf("corrupt last segment is truncated to %d", info.Size())
+	}
+
+	file.WriteAt(original, int64(FrameSize)*10+20)
+	file.Close()
+
+	// a bad frame in the middle of the log is corruption, not a torn tail
+	file, err = os.OpenFile(segments[0], os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteAt([]byte{0xff}, int64(FrameSize)*10+20)
+	file.Close()
+
+	_, err = replayIDs(dir)
+	if err == nil {
+		t.Fatal("corrupt segment is replayed without an error")
+	}
+}
+
+func BenchmarkWAL_Write(b *testing.B) {
+	for _, syncEvery := range []int{0, 1000, 100, 1} {
+		b.Run("SyncEvery="+strconv.Itoa(syncEvery), func(b *testing.B) {
+			log, err := Open(b.TempDir(), Options{SyncEvery: syncEvery})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			packet := ledger.Packet{}
+			b.SetBytes(int64(FrameSize))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				packet.ID = int64(i)
+				err := log.Append(&packet)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			err = log.Close()
+			if err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
+
+func BenchmarkWAL_Replay(b *testing.B) {
+	for _, records := range []int{10000, 1000000} {
+		b.Run("Records="+strconv.Itoa(records), func(b *testing.B) {
+			dir := b.TempDir()
+
+			log, err := Open(dir, Options{SegmentSize: 16 << 20})
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := appendPackets(log, 0, records); err != nil {
+				b.Fatal(err)
+			}
+			if err := log.Close(); err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(int64(FrameSize * records))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				replayed := 0
+				err := Replay(dir, func(packet *ledger.Packet) error {
+					replayed++
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if replayed != records {
+					b.Fatalf("replayed %d packets, expected %d", replayed, records)
+				}
+			}
+
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(records), "ns/entry")
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package workload provides CPU jobs of a calibrated cost for the benchmarks
+// which need their goroutines to do some real work. Jobs return their result,
+// callers keep it locally and add it to Sink once they are done, so the
+// compiler is not able to eliminate the work and the jobs themselves share
+// nothing between goroutines.
+package workload
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sink accumulates the results of all jobs, it is meant to be added to once
+// per goroutine or benchmark rather than once per job.
+var Sink atomic.Uint64
+
+// Kind runs the given number of iterations of a particular kind of work,
+// starting from seed, and returns a value depending on all of them.
+type Kind func(seed uint64, iterations int) uint64
+
+// Arithmetic is a chain of dependent multiplications, bound by the ALU.
+func Arithmetic(seed uint64, iterations int) uint64 {
+	x := seed | 1
+	for i := 0; i < iterations; i++ {
+		x = x*6364136223846793005 + 1442695040888963407
+		x ^= x >> 33
+	}
+	return x
+}
+
+var hashBlock = []byte("the quick brown fox jumps over the lazy dog, 0123456789abcdefgh")
+
+// Hash runs FNV-1a over a 64 byte block per iteration.
+func Hash(seed uint64, iterations int) uint64 {
+	hash := uint64(14695981039346656037) ^ seed
+	for i := 0; i < iterations; i++ {
+		for _, b := range hashBlock {
+			hash ^= uint64(b)
+			hash *= 1099511628211
+		}
+	}
+	return hash
+}
+
+// chainSize makes the pointer chase chain 16MB, larger than usual caches.
+const chainSize = 1 << 22
+
+var (
+	chain     []uint32
+	chainOnce sync.Once
+)
+
+// Chase follows a random cycle through a large array, every iteration is a
+// dependent load which most likely misses the cache.
+func Chase(seed uint64, iterations int) uint64 {
+	chainOnce.Do(func() {
+		chain = make([]uint32, chainSize)
+		for i := range chain {
+			chain[i] = uint32(i)
+		}
+
+		// Sattolo's algorithm produces a single cycle over all entries
+		random := rand.New(rand.NewSource(1))
+		for i := len(chain) - 1; i > 0; i-- {
+			j := random.Intn(i)
+			chain[i], chain[j] = chain[j], chain[i]
+		}
+	})
+
+	// start every seed from a different place, so short runs do not keep
+	// walking the same cached prefix
+	pos := uint32(seed * 2654435761 % chainSize)
+	for i := 0; i < iterations; i++ {
+		pos = chain[pos]
+	}
+	return uint64(pos)
+}
+
+type Job struct {
+	Kind       Kind
+	Iterations int
+}
+
+// Run runs the job from seed and returns its result, an empty job returns
+// the seed.
+func (job Job) Run(seed uint64) uint64 {
+	if job.Iterations == 0 {
+		return seed
+	}
+
+	return job.Kind(seed, job.Iterations)
+}
+
+// calibrationTime is how long the calibration runs a kind of work for.
+const calibrationTime = 20 * time.Millisecond
+
+// Calibrate returns a job of the given kind which takes about cost to run on
+// this machine.
+func Calibrate(kind Kind, cost time.Duration) Job {
+	if cost <= 0 {
+		return Job{Kind: kind}
+	}
+
+	// warm up, the chase chain is built on the first run
+	kind(0, 1)
+
+	for iterations := 1; ; iterations *= 2 {
+		started := time.Now()
+		Sink.Add(kind(uint64(iterations), iterations))
+		elapsed := time.Since(started)
+		if elapsed >= calibrationTime {
+			scaled := int(float64(iterations) * float64(cost) / float64(elapsed))
+			if scaled < 1 {
+				scaled = 1
+			}
+			return Job{Kind: kind, Iterations: scaled}
+		}
+	}
+}